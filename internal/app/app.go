@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/quinncuatro/hass-cli/internal/cli"
@@ -8,11 +9,38 @@ import (
 )
 
 func Run(args []string) error {
-	cfg, err := config.Load()
+	opts, args, err := cli.ParseGlobalFlags(args)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
 	}
+	opts.Version = Version
 
-	commander := cli.NewCommander(cfg)
-	return commander.Execute(args)
-}
\ No newline at end of file
+	load := config.Load
+	switch {
+	case cli.InspectsConfig(args):
+		load = config.LoadReadOnly
+	case !cli.NeedsToken(args):
+		load = config.LoadWithoutToken
+	}
+
+	cfg, err := load()
+	var validationErr *config.ValidationError
+	switch {
+	case errors.As(err, &validationErr) && cli.InspectsConfig(args):
+		cfg = config.DefaultConfig()
+	case err != nil:
+		return cli.Explain(fmt.Errorf("failed to load configuration: %w", err))
+	}
+	cli.PrintConfigMigration(cfg)
+	cli.PrintConfigWarnings(cfg)
+
+	commander := cli.NewCommander(cfg, opts)
+	defer commander.Close()
+
+	return cli.Explain(commander.Execute(args))
+}
+
+// ExitCode returns the process exit code for an error returned by Run.
+func ExitCode(err error) int {
+	return cli.ExitCode(err)
+}
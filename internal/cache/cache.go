@@ -1,104 +1,299 @@
 package cache
 
 import (
+	"container/list"
+	"context"
 	"sync"
 	"time"
 )
 
-type Cache interface {
-	Get(key string) (interface{}, bool)
-	Set(key string, value interface{}, ttl time.Duration)
-	Delete(key string)
-	Clear()
-	Size() int
+// Options configures a Cache. Zero values disable the corresponding limit.
+type Options struct {
+	// MaxEntries bounds the cache; the least recently used entry is evicted
+	// when it is exceeded.
+	MaxEntries int
+	// TTL is how long a value is fresh.
+	TTL time.Duration
+	// StaleTTL is how long after expiring a value may still be served by
+	// GetOrLoad while it is reloaded in the background.
+	StaleTTL time.Duration
+	// CleanupInterval is how often expired entries are swept.
+	CleanupInterval time.Duration
+	// LoadTimeout bounds each load started by GetOrLoad. Loads are shared
+	// and outlive the caller that started them, so they do not use its
+	// deadline.
+	LoadTimeout time.Duration
 }
 
-type MemoryCache struct {
-	mu     sync.RWMutex
-	items  map[string]*cacheItem
-	maxTTL time.Duration
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
 }
 
-type cacheItem struct {
-	value     interface{}
+// Cache is an in-memory LRU cache safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	opts Options
+
+	mu       sync.Mutex
+	items    map[K]*list.Element
+	order    *list.List
+	inflight map[K]*loadCall[V]
+	stats    Stats
+	// generation is bumped by Delete and Clear, so loads that started
+	// before them do not store what they fetched.
+	generation uint64
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
 	expiresAt time.Time
 }
 
-func NewMemoryCache(maxTTL time.Duration) *MemoryCache {
-	cache := &MemoryCache{
-		items:  make(map[string]*cacheItem),
-		maxTTL: maxTTL,
-	}
-	
-	go cache.cleanup()
-	
-	return cache
-}
-
-func (c *MemoryCache) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	
-	item, exists := c.items[key]
-	if !exists {
-		return nil, false
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func New[K comparable, V any](opts Options) *Cache[K, V] {
+	c := &Cache[K, V]{
+		opts:     opts,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+		inflight: make(map[K]*loadCall[V]),
+		done:     make(chan struct{}),
 	}
-	
-	if time.Now().After(item.expiresAt) {
-		delete(c.items, key)
-		return nil, false
+
+	if opts.CleanupInterval > 0 {
+		c.wg.Add(1)
+		go c.cleanup()
 	}
-	
-	return item.value, true
+
+	return c
 }
 
-func (c *MemoryCache) Set(key string, value interface{}, ttl time.Duration) {
+// Get returns the value for key if it is present and fresh.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	
-	if ttl > c.maxTTL {
-		ttl = c.maxTTL
-	}
-	
-	c.items[key] = &cacheItem{
-		value:     value,
-		expiresAt: time.Now().Add(ttl),
+
+	if e, ok := c.lookup(key); ok && time.Now().Before(e.expiresAt) {
+		c.stats.Hits++
+		return e.value, true
 	}
+
+	c.stats.Misses++
+	var zero V
+	return zero, false
 }
 
-func (c *MemoryCache) Delete(key string) {
+// Set stores value for key using the cache's TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.TTL)
+}
+
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	
-	delete(c.items, key)
+
+	c.store(key, value, ttl)
 }
 
-func (c *MemoryCache) Clear() {
+// Delete removes key. A load of key already under way is not stored, and
+// later callers of GetOrLoad start a new one.
+func (c *Cache[K, V]) Delete(key K) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	
-	c.items = make(map[string]*cacheItem)
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+	delete(c.inflight, key)
+	c.generation++
 }
 
-func (c *MemoryCache) Size() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	
+// Clear removes every key, and like Delete keeps loads under way from
+// storing their values.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+	c.inflight = make(map[K]*loadCall[V])
+	c.generation++
+}
+
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	return len(c.items)
 }
 
-func (c *MemoryCache) cleanup() {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-	
-	for range ticker.C {
-		c.mu.Lock()
-		now := time.Now()
-		for key, item := range c.items {
-			if now.After(item.expiresAt) {
-				delete(c.items, key)
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.items)
+	return stats
+}
+
+// GetOrLoad returns the cached value for key, calling load on a miss.
+// Concurrent callers for the same key share one load. A value that expired
+// less than StaleTTL ago is returned immediately while it is reloaded in the
+// background.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load func(context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+
+	if e, ok := c.lookup(key); ok {
+		value, now := e.value, time.Now()
+		if now.Before(e.expiresAt) {
+			c.stats.Hits++
+			c.mu.Unlock()
+			return value, nil
+		}
+
+		if now.Before(e.expiresAt.Add(c.opts.StaleTTL)) {
+			c.stats.Hits++
+			if _, loading := c.inflight[key]; !loading {
+				c.startLoad(ctx, key, load)
 			}
+			c.mu.Unlock()
+			return value, nil
+		}
+	}
+
+	c.stats.Misses++
+	call, loading := c.inflight[key]
+	if !loading {
+		call = c.startLoad(ctx, key, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Close stops the cleanup goroutine and waits for background loads.
+func (c *Cache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+}
+
+// startLoad runs load in its own goroutine and must be called with mu held.
+// The load keeps ctx's values but not its cancellation: other callers may be
+// waiting for it after the one that started it has given up.
+func (c *Cache[K, V]) startLoad(ctx context.Context, key K, load func(context.Context) (V, error)) *loadCall[V] {
+	call := &loadCall[V]{done: make(chan struct{})}
+	c.inflight[key] = call
+	generation := c.generation
+
+	ctx = context.WithoutCancel(ctx)
+	cancel := context.CancelFunc(func() {})
+	if c.opts.LoadTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.opts.LoadTimeout)
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer cancel()
+
+		call.value, call.err = load(ctx)
+
+		c.mu.Lock()
+		if call.err == nil && c.generation == generation {
+			c.store(key, call.value, c.opts.TTL)
+		}
+		if c.inflight[key] == call {
+			delete(c.inflight, key)
 		}
 		c.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	return call
+}
+
+// lookup finds key and marks it as recently used. mu must be held.
+func (c *Cache[K, V]) lookup(key K) (*entry[K, V], bool) {
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*entry[K, V]), true
+}
+
+// store inserts or replaces key and evicts beyond MaxEntries. mu must be held.
+func (c *Cache[K, V]) store(key K, value V, ttl time.Duration) {
+	expiresAt := time.Now().Add(ttl)
+
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for c.opts.MaxEntries > 0 && len(c.items) > c.opts.MaxEntries {
+		c.removeElement(c.order.Back())
+		c.stats.Evictions++
 	}
-}
\ No newline at end of file
+}
+
+func (c *Cache[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry[K, V]).key)
+}
+
+func (c *Cache[K, V]) cleanup() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.opts.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.removeExpired()
+		}
+	}
+}
+
+func (c *Cache[K, V]) removeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := time.Now().Add(-c.opts.StaleTTL)
+	for element := c.order.Back(); element != nil; {
+		prev := element.Prev()
+		if element.Value.(*entry[K, V]).expiresAt.Before(cutoff) {
+			c.removeElement(element)
+		}
+		element = prev
+	}
+}
@@ -1,63 +1,263 @@
 package cache
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
-func TestMemoryCache_SetAndGet(t *testing.T) {
-	cache := NewMemoryCache(time.Hour)
-	
-	cache.Set("test-key", "test-value", time.Minute)
-	
+func TestCache_SetAndGet(t *testing.T) {
+	cache := New[string, string](Options{TTL: time.Minute})
+	defer cache.Close()
+
+	cache.Set("test-key", "test-value")
+
 	value, exists := cache.Get("test-key")
 	if !exists {
 		t.Fatal("Expected key to exist")
 	}
-	
+
 	if value != "test-value" {
 		t.Fatalf("Expected 'test-value', got %v", value)
 	}
 }
 
-func TestMemoryCache_Expiration(t *testing.T) {
-	cache := NewMemoryCache(time.Hour)
-	
-	cache.Set("test-key", "test-value", time.Millisecond*10)
-	
+func TestCache_Expiration(t *testing.T) {
+	cache := New[string, string](Options{TTL: time.Minute})
+	defer cache.Close()
+
+	cache.SetWithTTL("test-key", "test-value", time.Millisecond*10)
+
 	time.Sleep(time.Millisecond * 20)
-	
+
 	_, exists := cache.Get("test-key")
 	if exists {
 		t.Fatal("Expected key to be expired")
 	}
 }
 
-func TestMemoryCache_Delete(t *testing.T) {
-	cache := NewMemoryCache(time.Hour)
-	
-	cache.Set("test-key", "test-value", time.Minute)
+func TestCache_Delete(t *testing.T) {
+	cache := New[string, string](Options{TTL: time.Minute})
+	defer cache.Close()
+
+	cache.Set("test-key", "test-value")
 	cache.Delete("test-key")
-	
+
 	_, exists := cache.Get("test-key")
 	if exists {
 		t.Fatal("Expected key to be deleted")
 	}
 }
 
-func TestMemoryCache_Clear(t *testing.T) {
-	cache := NewMemoryCache(time.Hour)
-	
-	cache.Set("key1", "value1", time.Minute)
-	cache.Set("key2", "value2", time.Minute)
-	
-	if cache.Size() != 2 {
-		t.Fatalf("Expected size 2, got %d", cache.Size())
+func TestCache_Clear(t *testing.T) {
+	cache := New[string, string](Options{TTL: time.Minute})
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	if cache.Len() != 2 {
+		t.Fatalf("Expected size 2, got %d", cache.Len())
 	}
-	
+
 	cache.Clear()
-	
-	if cache.Size() != 0 {
-		t.Fatalf("Expected size 0 after clear, got %d", cache.Size())
+
+	if cache.Len() != 0 {
+		t.Fatalf("Expected size 0 after clear, got %d", cache.Len())
+	}
+}
+
+func TestCache_LRUEviction(t *testing.T) {
+	cache := New[string, int](Options{TTL: time.Minute, MaxEntries: 2})
+	defer cache.Close()
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+	cache.Set("c", 3)
+
+	if _, exists := cache.Get("b"); exists {
+		t.Error("Expected least recently used key to be evicted")
+	}
+	if _, exists := cache.Get("a"); !exists {
+		t.Error("Expected recently used key to survive")
 	}
-}
\ No newline at end of file
+
+	stats := cache.Stats()
+	if stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("Expected 1 eviction and 2 entries, got %+v", stats)
+	}
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss, got %+v", stats)
+	}
+}
+
+func TestCache_GetOrLoadDeduplicates(t *testing.T) {
+	cache := New[string, int](Options{TTL: time.Minute})
+	defer cache.Close()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := cache.GetOrLoad(context.Background(), "key", load); err != nil || value != 42 {
+				t.Errorf("Expected 42, got %d (err=%v)", value, err)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected a single load, got %d", calls.Load())
+	}
+}
+
+func TestCache_GetOrLoadOutlivesCancelledCaller(t *testing.T) {
+	cache := New[string, int](Options{TTL: time.Minute, LoadTimeout: time.Minute})
+	defer cache.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return 42, nil
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := cache.GetOrLoad(cancelled, "key", load)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan int, 1)
+	go func() {
+		value, err := cache.GetOrLoad(context.Background(), "key", load)
+		if err != nil {
+			t.Errorf("Expected the live caller to get the value, got %v", err)
+		}
+		second <- value
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled caller to give up, got %v", err)
+	}
+	close(release)
+
+	if value := <-second; value != 42 {
+		t.Errorf("Expected 42, got %d", value)
+	}
+}
+
+func TestCache_DeleteDuringLoad(t *testing.T) {
+	cache := New[string, int](Options{TTL: time.Minute})
+	defer cache.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan int, 1)
+	go func() {
+		value, _ := cache.GetOrLoad(context.Background(), "key", func(ctx context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		done <- value
+	}()
+	<-started
+
+	cache.Delete("key")
+	close(release)
+	<-done
+
+	if value, ok := cache.Get("key"); ok {
+		t.Errorf("Expected a load started before Delete not to be stored, got %d", value)
+	}
+
+	value, err := cache.GetOrLoad(context.Background(), "key", func(ctx context.Context) (int, error) {
+		return 2, nil
+	})
+	if err != nil || value != 2 {
+		t.Errorf("Expected a new load after Delete, got %d (err=%v)", value, err)
+	}
+}
+
+func TestCache_GetOrLoadServesStale(t *testing.T) {
+	cache := New[string, int](Options{TTL: time.Minute, StaleTTL: time.Hour})
+
+	cache.SetWithTTL("key", 1, -time.Second)
+
+	value, err := cache.GetOrLoad(context.Background(), "key", func(ctx context.Context) (int, error) {
+		return 2, nil
+	})
+	if err != nil || value != 1 {
+		t.Fatalf("Expected stale value 1, got %d (err=%v)", value, err)
+	}
+
+	cache.Close()
+
+	if value, _ := cache.Get("key"); value != 2 {
+		t.Errorf("Expected background reload to store 2, got %d", value)
+	}
+}
+
+func TestCache_GetOrLoadError(t *testing.T) {
+	cache := New[string, int](Options{TTL: time.Minute})
+	defer cache.Close()
+
+	failure := errors.New("unreachable")
+	_, err := cache.GetOrLoad(context.Background(), "key", func(ctx context.Context) (int, error) {
+		return 0, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected load error, got %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Error("Expected failed load not to be cached")
+	}
+}
+
+func TestCache_CloseStopsCleanup(t *testing.T) {
+	cache := New[string, int](Options{TTL: time.Millisecond, CleanupInterval: time.Millisecond})
+	cache.Set("key", 1)
+
+	time.Sleep(20 * time.Millisecond)
+	if cache.Len() != 0 {
+		t.Error("Expected cleanup to remove expired entries")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cache.Close()
+		cache.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Close to return")
+	}
+}
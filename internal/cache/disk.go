@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const diskEntrySuffix = ".json"
+
+// DiskCache persists JSON-encoded values as one file per key. Entries written
+// under a different namespace (another Home Assistant instance, token or
+// hass-cli version) are treated as missing.
+type DiskCache struct {
+	dir            string
+	namespace      string
+	refreshTimeout time.Duration
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+	wg         sync.WaitGroup
+}
+
+type diskEntry struct {
+	Namespace string          `json:"namespace"`
+	StoredAt  time.Time       `json:"stored_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// DiskEntryInfo describes a cache file for display.
+type DiskEntryInfo struct {
+	Key      string
+	StoredAt time.Time
+	Size     int64
+	Valid    bool
+}
+
+func NewDiskCache(dir, namespace string, refreshTimeout time.Duration) *DiskCache {
+	return &DiskCache{
+		dir:            dir,
+		namespace:      namespace,
+		refreshTimeout: refreshTimeout,
+		refreshing:     make(map[string]bool),
+	}
+}
+
+// DefaultDir returns the per-user cache directory for hass-cli.
+func DefaultDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "hass"), nil
+}
+
+func (d *DiskCache) Dir() string {
+	return d.dir
+}
+
+// Load decodes the entry for key into value and returns when it was stored.
+func (d *DiskCache) Load(key string, value interface{}) (time.Time, bool) {
+	entry, err := d.readEntry(key)
+	if err != nil || entry.Namespace != d.namespace {
+		return time.Time{}, false
+	}
+
+	if err := json.Unmarshal(entry.Data, value); err != nil {
+		return time.Time{}, false
+	}
+
+	return entry.StoredAt, true
+}
+
+// Store writes value for key atomically with owner-only permissions.
+func (d *DiskCache) Store(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	entry, err := json.Marshal(diskEntry{
+		Namespace: d.namespace,
+		StoredAt:  time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(d.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(d.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(entry); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DiskCache) Delete(key string) error {
+	err := os.Remove(d.path(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Clear removes every cache entry, whatever its namespace.
+func (d *DiskCache) Clear() error {
+	entries, err := d.Entries()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := d.Delete(entry.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DiskCache) Entries() ([]DiskEntryInfo, error) {
+	files, err := os.ReadDir(d.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DiskEntryInfo
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), diskEntrySuffix) {
+			continue
+		}
+
+		key := strings.TrimSuffix(file.Name(), diskEntrySuffix)
+		info := DiskEntryInfo{Key: key}
+
+		if stat, err := file.Info(); err == nil {
+			info.Size = stat.Size()
+		}
+		if entry, err := d.readEntry(key); err == nil {
+			info.StoredAt = entry.StoredAt
+			info.Valid = entry.Namespace == d.namespace
+		}
+
+		entries = append(entries, info)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries, nil
+}
+
+// Wait blocks until background refreshes started by Fetch have finished.
+func (d *DiskCache) Wait() {
+	d.wg.Wait()
+}
+
+func (d *DiskCache) readEntry(key string) (*diskEntry, error) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry diskEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.dir, key+diskEntrySuffix)
+}
+
+// Fetch returns the cached value for key. A fresh entry is returned as is; a
+// stale one is returned immediately while a background refresh replaces it;
+// a missing one is fetched synchronously. The boolean reports whether the
+// value came from the cache.
+func Fetch[T any](ctx context.Context, d *DiskCache, key string, ttl time.Duration, fetch func(context.Context) (T, error)) (T, bool, error) {
+	var cached T
+	if storedAt, ok := d.Load(key, &cached); ok {
+		if time.Since(storedAt) >= ttl {
+			d.refreshInBackground(ctx, key, func(ctx context.Context) (interface{}, error) {
+				return fetch(ctx)
+			})
+		}
+		return cached, true, nil
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		return value, false, err
+	}
+
+	_ = d.Store(key, value)
+	return value, false, nil
+}
+
+func (d *DiskCache) refreshInBackground(ctx context.Context, key string, fetch func(context.Context) (interface{}, error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.refreshing[key] {
+		return
+	}
+	d.refreshing[key] = true
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer func() {
+			d.mu.Lock()
+			delete(d.refreshing, key)
+			d.mu.Unlock()
+		}()
+
+		refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.refreshTimeout)
+		defer cancel()
+
+		value, err := fetch(refreshCtx)
+		if err != nil {
+			return
+		}
+		_ = d.Store(key, value)
+	}()
+}
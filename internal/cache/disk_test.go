@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDiskCache_StoreAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	disk := NewDiskCache(dir, "instance-a", time.Second)
+
+	if err := disk.Store("states", []string{"light.kitchen"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var value []string
+	if _, ok := disk.Load("states", &value); !ok || len(value) != 1 || value[0] != "light.kitchen" {
+		t.Fatalf("expected stored value, got %v (ok=%v)", value, ok)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "states.json"))
+	if err != nil {
+		t.Fatalf("expected cache file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions 0600, got %o", info.Mode().Perm())
+	}
+
+	other := NewDiskCache(dir, "instance-b", time.Second)
+	if _, ok := other.Load("states", &value); ok {
+		t.Error("expected entries from another namespace to be ignored")
+	}
+}
+
+func TestDiskCache_Clear(t *testing.T) {
+	disk := NewDiskCache(t.TempDir(), "ns", time.Second)
+	_ = disk.Store("states", 1)
+	_ = disk.Store("services", 2)
+
+	entries, err := disk.Entries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v (err=%v)", entries, err)
+	}
+
+	if err := disk.Clear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := disk.Entries(); len(entries) != 0 {
+		t.Errorf("expected no entries after clear, got %v", entries)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	disk := NewDiskCache(t.TempDir(), "ns", time.Second)
+	var calls atomic.Int32
+	fetch := func(ctx context.Context) (int, error) {
+		return int(calls.Add(1)), nil
+	}
+
+	value, fromCache, err := Fetch(context.Background(), disk, "key", time.Hour, fetch)
+	if err != nil || value != 1 || fromCache {
+		t.Fatalf("expected synchronous fetch, got %d fromCache=%v err=%v", value, fromCache, err)
+	}
+
+	value, fromCache, _ = Fetch(context.Background(), disk, "key", time.Hour, fetch)
+	if value != 1 || !fromCache {
+		t.Fatalf("expected fresh cached value, got %d fromCache=%v", value, fromCache)
+	}
+
+	// A stale entry is served immediately and refreshed in the background.
+	value, fromCache, _ = Fetch(context.Background(), disk, "key", 0, fetch)
+	if value != 1 || !fromCache {
+		t.Fatalf("expected stale cached value, got %d fromCache=%v", value, fromCache)
+	}
+	disk.Wait()
+
+	var refreshed int
+	if _, ok := disk.Load("key", &refreshed); !ok || refreshed != 2 {
+		t.Errorf("expected background refresh to store 2, got %d", refreshed)
+	}
+}
+
+func TestFetch_Error(t *testing.T) {
+	disk := NewDiskCache(t.TempDir(), "ns", time.Second)
+	failure := errors.New("unreachable")
+
+	_, _, err := Fetch(context.Background(), disk, "key", time.Hour, func(ctx context.Context) (int, error) {
+		return 0, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected fetch error, got %v", err)
+	}
+	if entries, _ := disk.Entries(); len(entries) != 0 {
+		t.Errorf("expected failed fetch not to be cached, got %v", entries)
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/cache"
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/config"
+)
+
+const servicesCacheKey = "services"
+
+// cacheNamespace ties cache entries to one Home Assistant instance, token and
+// hass-cli version so switching any of them never serves foreign data.
+func cacheNamespace(cfg *config.Config, version string) string {
+	sum := sha256.Sum256([]byte(version + "\x00" + cfg.HomeAssistant.URL + "\x00" + cfg.HomeAssistant.Token))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (c *Commander) handleCacheCommand(args []string) error {
+	if len(args) != 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("usage: cache show|clear"))
+	}
+
+	disk := c.disk
+	if disk == nil {
+		dir, err := cache.DefaultDir()
+		if err != nil {
+			return fmt.Errorf("failed to locate cache directory: %w", err)
+		}
+		disk = cache.NewDiskCache(dir, cacheNamespace(c.config, c.options.Version), c.config.HomeAssistant.Timeout)
+	}
+
+	switch args[0] {
+	case "show":
+		return c.showCache(disk)
+	case "clear":
+		entries, err := disk.Entries()
+		if err != nil {
+			return fmt.Errorf("failed to read cache: %w", err)
+		}
+		if err := disk.Clear(); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+		c.invalidateStates()
+		fmt.Fprintf(c.out, "✓ Cleared %d cache entries from %s\n", len(entries), disk.Dir())
+		return nil
+	default:
+		return withExitCode(ExitUsage, fmt.Errorf("unknown cache command: %s", args[0]))
+	}
+}
+
+func (c *Commander) invalidateStates() {
+	if c.cached != nil {
+		c.cached.Invalidate()
+	}
+}
+
+func (c *Commander) showCache(disk *cache.DiskCache) error {
+	entries, err := disk.Entries()
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	fmt.Fprintf(c.out, "Cache directory: %s\n", disk.Dir())
+	fmt.Fprintf(c.out, "Cache timeout: %v\n", c.config.HomeAssistant.CacheTimeout)
+	if c.disk == nil {
+		fmt.Fprintln(c.out, "Cache is disabled (--no-cache or cache_timeout: 0)")
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(c.out, "No cache entries")
+		return nil
+	}
+
+	fmt.Fprintln(c.out)
+	for _, entry := range entries {
+		status := "fresh"
+		switch {
+		case !entry.Valid:
+			status = "other instance or version"
+		case time.Since(entry.StoredAt) >= c.config.HomeAssistant.CacheTimeout:
+			status = "stale"
+		}
+
+		fmt.Fprintf(c.out, "  %-12s %8.1f KB  stored %s ago (%s)\n",
+			entry.Key, float64(entry.Size)/1024, time.Since(entry.StoredAt).Round(time.Second), status)
+	}
+
+	return nil
+}
+
+// services returns the service catalog, served from the disk cache when it
+// is enabled.
+func (c *Commander) services(ctx context.Context) ([]client.ServiceDomain, error) {
+	if c.disk == nil {
+		return c.client.GetServices(ctx)
+	}
+
+	services, _, err := cache.Fetch(ctx, c.disk, servicesCacheKey, c.config.HomeAssistant.CacheTimeout, c.client.GetServices)
+	return services, err
+}
+
+func (c *Commander) showServices(ctx context.Context) error {
+	domains, err := c.services(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get services: %w", err)
+	}
+
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Domain < domains[j].Domain
+	})
+
+	for _, domain := range domains {
+		names := make([]string, 0, len(domain.Services))
+		for name := range domain.Services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(c.out, "%s:\n", domain.Domain)
+		for _, name := range names {
+			fmt.Fprintf(c.out, "  %s\n", name)
+		}
+	}
+
+	return nil
+}
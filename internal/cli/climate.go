@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+// hvacModes are the HVAC modes Home Assistant defines. Each except off can be
+// used as an action, optionally followed by a temperature.
+var hvacModes = []string{"heat", "cool", "heat_cool", "auto", "dry", "fan_only"}
+
+// temperatureUnitPattern finds temperatures given with a °C or °F suffix.
+var temperatureUnitPattern = regexp.MustCompile(`(?i)\d\s*°?\s*[cf]\b`)
+
+func (c *Commander) handleClimateWithValue(ctx context.Context, caps entity.Capabilities, action, value string) error {
+	// The unit system is only needed to convert temperatures given in the
+	// other unit, so it is not fetched otherwise.
+	var unit string
+	if temperatureUnitPattern.MatchString(value) {
+		status, err := c.client.GetSystemStatus(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get unit system: %w", err)
+		}
+		unit = status.UnitSystem.Temperature
+	}
+
+	service, serviceData, err := climateServiceData(caps, strings.ToLower(action), value, unit)
+	if err != nil {
+		return err
+	}
+
+	target := map[string]interface{}{
+		"entity_id": caps.EntityID,
+	}
+
+	_, err = c.client.CallService(ctx, "climate", service, target, serviceData)
+	return err
+}
+
+// climateShorthand handles "hass bedroom cool 70" and "hass bedroom auto",
+// where an HVAC mode takes the place of the entity type, by rewriting them to
+// "hass bedroom climate cool 70" before the entity is resolved.
+func climateShorthand(area, entityType, action, value string) (string, string, string, string) {
+	if value != "" {
+		return area, entityType, action, value
+	}
+
+	// "hass bedroom cool 70": the mode is the entity type.
+	if slices.Contains(hvacModes, strings.ToLower(entityType)) {
+		if _, _, err := splitTemperatureUnit(action); err == nil {
+			return area, "climate", entityType, action
+		}
+		return area, entityType, action, value
+	}
+
+	// "hass bedroom auto": the area was read as the entity type.
+	if area == "" && slices.Contains(hvacModes, strings.ToLower(action)) &&
+		!slices.Contains(entity.EntityTypeKeywords(), strings.ToLower(entityType)) {
+		return entityType, "climate", action, ""
+	}
+
+	return area, entityType, action, value
+}
+
+// climateServiceData maps an action and optional value to a climate service
+// call. unit is Home Assistant's temperature unit, used to convert values
+// given as °C or °F:
+//
+//	temperature 72, temp 22°C    target temperature
+//	temperature 68-74, range 20 to 23
+//	                             target_temp_low and target_temp_high
+//	heat, cool 70, auto 68-74    HVAC mode, with an optional temperature
+//	mode heat_cool               HVAC mode
+//	fan high, swing vertical     fan and swing modes
+//	preset eco, eco              preset mode
+//	humidity 45                  target humidity
+func climateServiceData(caps entity.Capabilities, action, value, unit string) (string, map[string]interface{}, error) {
+	switch action {
+	case "temp", "temperature", "target", "range":
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		serviceData, err := temperatureData(caps, value, unit)
+		return "set_temperature", serviceData, err
+
+	case "mode", "hvac_mode":
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		mode, err := caps.MatchOption("mode", value, caps.HVACModes)
+		if err != nil {
+			return "", nil, err
+		}
+		return "set_hvac_mode", map[string]interface{}{"hvac_mode": mode}, nil
+
+	case "fan", "fan_mode":
+		return climateModeData(caps, "fan_mode", entity.ClimateFeatureFanMode, caps.FanModes, value)
+
+	case "swing", "swing_mode":
+		return climateModeData(caps, "swing_mode", entity.ClimateFeatureSwingMode, caps.SwingModes, value)
+
+	case "preset", "preset_mode":
+		return climateModeData(caps, "preset_mode", entity.ClimateFeaturePresetMode, caps.PresetModes, value)
+
+	case "humidity":
+		if !caps.Has(entity.ClimateFeatureTargetHumidity) {
+			return "", nil, caps.Unsupported("humidity", "it has no humidity control")
+		}
+		humidity, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid humidity value: %s", value)
+		}
+		if humidity < 0 || humidity > 100 {
+			return "", nil, fmt.Errorf("humidity must be between 0 and 100")
+		}
+		if err := caps.CheckRange("humidity", humidity, caps.MinHumidity, caps.MaxHumidity); err != nil {
+			return "", nil, err
+		}
+		return "set_humidity", map[string]interface{}{"humidity": int(math.Round(humidity))}, nil
+	}
+
+	if slices.Contains(hvacModes, action) {
+		mode, err := caps.MatchOption("mode", action, caps.HVACModes)
+		if err != nil {
+			return "", nil, err
+		}
+		if value == "" {
+			return "set_hvac_mode", map[string]interface{}{"hvac_mode": mode}, nil
+		}
+		serviceData, err := temperatureData(caps, value, unit)
+		if err != nil {
+			return "", nil, err
+		}
+		serviceData["hvac_mode"] = mode
+		return "set_temperature", serviceData, nil
+	}
+
+	// A preset can be named directly, as in "hass living thermostat eco".
+	if value == "" && caps.Has(entity.ClimateFeaturePresetMode) && len(caps.PresetModes) > 0 {
+		if preset, err := caps.MatchOption("preset", action, caps.PresetModes); err == nil {
+			return "set_preset_mode", map[string]interface{}{"preset_mode": preset}, nil
+		}
+	}
+
+	return "", nil, caps.Unsupported(action, "")
+}
+
+// climateModeData builds a set_fan_mode, set_swing_mode or set_preset_mode
+// call, checking feature and the entity's list of modes.
+func climateModeData(caps entity.Capabilities, field string, feature int, options []string, value string) (string, map[string]interface{}, error) {
+	name := strings.ReplaceAll(field, "_", " ")
+	if !caps.Has(feature) {
+		return "", nil, caps.Unsupported(name, "it has no "+name+"s")
+	}
+	if value == "" {
+		return "", nil, fmt.Errorf("%s needs a value", name)
+	}
+
+	mode, err := caps.MatchOption(name, value, options)
+	if err != nil {
+		return "", nil, err
+	}
+	return "set_" + field, map[string]interface{}{field: mode}, nil
+}
+
+// temperatureData returns set_temperature data for a single temperature or a
+// low-high range.
+func temperatureData(caps entity.Capabilities, value, unit string) (map[string]interface{}, error) {
+	if low, high, ok := splitRange(value); ok {
+		if !caps.Has(entity.ClimateFeatureTargetTemperatureRange) {
+			return nil, caps.Unsupported("temperature range", "it only accepts a single target temperature")
+		}
+		lowTemp, err := parseTemperature(caps, low, unit)
+		if err != nil {
+			return nil, err
+		}
+		highTemp, err := parseTemperature(caps, high, unit)
+		if err != nil {
+			return nil, err
+		}
+		if lowTemp > highTemp {
+			return nil, fmt.Errorf("low temperature %g is above high temperature %g", lowTemp, highTemp)
+		}
+		return map[string]interface{}{
+			"target_temp_low":  lowTemp,
+			"target_temp_high": highTemp,
+		}, nil
+	}
+
+	if !caps.Has(entity.ClimateFeatureTargetTemperature) {
+		reason := "it has no single target temperature"
+		if caps.Has(entity.ClimateFeatureTargetTemperatureRange) {
+			reason = "it only accepts a low-high temperature range, as in 68-74"
+		}
+		return nil, caps.Unsupported("temperature", reason)
+	}
+
+	temp, err := parseTemperature(caps, value, unit)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"temperature": temp}, nil
+}
+
+// splitRange splits "68-74", "68..74" or "68 to 74" into its two ends.
+func splitRange(value string) (string, string, bool) {
+	value = strings.TrimSpace(value)
+	for _, separator := range []string{" to ", ".."} {
+		if i := strings.Index(value, separator); i > 0 {
+			return strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+len(separator):]), true
+		}
+	}
+	// Skip the first character so a negative temperature is not split.
+	if len(value) > 1 {
+		if i := strings.Index(value[1:], "-"); i >= 0 {
+			i++
+			return strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseTemperature reads a temperature, converting it to unit when it is
+// given in the other one, and checks it against the entity's limits.
+func parseTemperature(caps entity.Capabilities, value, unit string) (float64, error) {
+	temp, scale, err := splitTemperatureUnit(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature value: %s", value)
+	}
+
+	if scale != "" {
+		system := temperatureScale(unit)
+		if system == "" {
+			return 0, fmt.Errorf("cannot convert %s: Home Assistant did not report its temperature unit", value)
+		}
+		if scale != system {
+			temp = roundTemperature(convertTemperature(temp, scale), system, caps.TempStep)
+		}
+	}
+
+	if err := caps.CheckRange("temperature", temp, caps.MinTemp, caps.MaxTemp); err != nil {
+		return 0, err
+	}
+	return temp, nil
+}
+
+// splitTemperatureUnit parses "72", "72F" or "22 °C", returning the scale
+// ("C" or "F") when one is given.
+func splitTemperatureUnit(value string) (float64, string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+
+	var scale string
+	if strings.HasSuffix(value, "C") || strings.HasSuffix(value, "F") {
+		scale = value[len(value)-1:]
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value[:len(value)-1]), "°"))
+	}
+
+	temp, err := strconv.ParseFloat(value, 64)
+	return temp, scale, err
+}
+
+// temperatureScale returns "C" or "F" for a unit such as "°C".
+func temperatureScale(unit string) string {
+	switch {
+	case strings.Contains(strings.ToUpper(unit), "F"):
+		return "F"
+	case strings.Contains(strings.ToUpper(unit), "C"):
+		return "C"
+	default:
+		return ""
+	}
+}
+
+// convertTemperature converts temp from scale to the other scale.
+func convertTemperature(temp float64, scale string) float64 {
+	if scale == "F" {
+		return (temp - 32) * 5 / 9
+	}
+	return temp*9/5 + 32
+}
+
+// roundTemperature rounds a converted temperature to the entity's step, or
+// to half degrees Celsius and whole degrees Fahrenheit.
+func roundTemperature(temp float64, scale string, step float64) float64 {
+	if step <= 0 {
+		step = 0.5
+		if scale == "F" {
+			step = 1
+		}
+	}
+	rounded := math.Round(temp/step) * step
+	return math.Round(rounded*100) / 100
+}
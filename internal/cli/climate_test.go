@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func climateCapabilities(features int) entity.Capabilities {
+	return entity.CapabilitiesOf(client.EntityState{
+		EntityID: "climate.living",
+		Attributes: map[string]interface{}{
+			"supported_features": float64(features),
+			"hvac_modes":         []interface{}{"off", "heat", "cool", "heat_cool"},
+			"fan_modes":          []interface{}{"auto", "low", "high"},
+			"swing_modes":        []interface{}{"off", "vertical"},
+			"preset_modes":       []interface{}{"home", "eco"},
+			"min_temp":           float64(45),
+			"max_temp":           float64(95),
+			"min_humidity":       float64(30),
+			"max_humidity":       float64(60),
+		},
+	})
+}
+
+func TestClimateServiceData(t *testing.T) {
+	full := climateCapabilities(entity.ClimateFeatureTargetTemperature | entity.ClimateFeatureTargetTemperatureRange |
+		entity.ClimateFeatureTargetHumidity | entity.ClimateFeatureFanMode | entity.ClimateFeaturePresetMode | entity.ClimateFeatureSwingMode)
+
+	tests := []struct {
+		action      string
+		value       string
+		wantService string
+		want        map[string]interface{}
+	}{
+		{"temperature", "72", "set_temperature", map[string]interface{}{"temperature": 72.0}},
+		{"temp", "22°C", "set_temperature", map[string]interface{}{"temperature": 72.0}},
+		{"temperature", "68-74", "set_temperature", map[string]interface{}{"target_temp_low": 68.0, "target_temp_high": 74.0}},
+		{"range", "20C to 23C", "set_temperature", map[string]interface{}{"target_temp_low": 68.0, "target_temp_high": 73.0}},
+		{"heat", "", "set_hvac_mode", map[string]interface{}{"hvac_mode": "heat"}},
+		{"heat", "70", "set_temperature", map[string]interface{}{"hvac_mode": "heat", "temperature": 70.0}},
+		{"mode", "Heat Cool", "set_hvac_mode", map[string]interface{}{"hvac_mode": "heat_cool"}},
+		{"fan", "HIGH", "set_fan_mode", map[string]interface{}{"fan_mode": "high"}},
+		{"swing", "vertical", "set_swing_mode", map[string]interface{}{"swing_mode": "vertical"}},
+		{"preset", "eco", "set_preset_mode", map[string]interface{}{"preset_mode": "eco"}},
+		{"eco", "", "set_preset_mode", map[string]interface{}{"preset_mode": "eco"}},
+		{"humidity", "45%", "set_humidity", map[string]interface{}{"humidity": 45}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" "+tt.value, func(t *testing.T) {
+			service, got, err := climateServiceData(full, tt.action, tt.value, "°F")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service != tt.wantService {
+				t.Errorf("expected climate.%s, got climate.%s", tt.wantService, service)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClimateServiceDataChecksCapabilities(t *testing.T) {
+	single := climateCapabilities(entity.ClimateFeatureTargetTemperature)
+
+	tests := []struct {
+		name   string
+		caps   entity.Capabilities
+		action string
+		value  string
+	}{
+		{"above max_temp", single, "temperature", "100"},
+		{"converted below min_temp", single, "temperature", "5C"},
+		{"range without support", single, "temperature", "68-74"},
+		{"single temperature on range-only", climateCapabilities(entity.ClimateFeatureTargetTemperatureRange), "temperature", "70"},
+		{"mode not offered", single, "mode", "dry"},
+		{"shorthand mode not offered", single, "auto", "70"},
+		{"fan mode without support", single, "fan", "high"},
+		{"unknown swing mode", climateCapabilities(entity.ClimateFeatureSwingMode), "swing", "horizontal"},
+		{"humidity without support", single, "humidity", "40"},
+		{"humidity out of range", climateCapabilities(entity.ClimateFeatureTargetHumidity), "humidity", "80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := climateServiceData(tt.caps, tt.action, tt.value, "°F")
+			if !errors.Is(err, entity.ErrNotSupported) {
+				t.Errorf("expected ErrNotSupported, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClimateShorthand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"bedroom", "heat", "70", ""}, []string{"bedroom", "climate", "heat", "70"}},
+		{[]string{"bedroom", "cool", "21C", ""}, []string{"bedroom", "climate", "cool", "21C"}},
+		{[]string{"", "bedroom", "auto", ""}, []string{"bedroom", "climate", "auto", ""}},
+		{[]string{"", "bedroom", "fan_only", ""}, []string{"bedroom", "climate", "fan_only", ""}},
+		{[]string{"", "thermostat", "heat", ""}, []string{"", "thermostat", "heat", ""}},
+		{[]string{"bedroom", "heat", "on", ""}, []string{"bedroom", "heat", "on", ""}},
+		{[]string{"bedroom", "thermostat", "heat", "70"}, []string{"bedroom", "thermostat", "heat", "70"}},
+		{[]string{"office", "ac", "fan", "high"}, []string{"office", "ac", "fan", "high"}},
+	}
+
+	for _, tt := range tests {
+		area, entityType, action, value := climateShorthand(tt.args[0], tt.args[1], tt.args[2], tt.args[3])
+		if got := []string{area, entityType, action, value}; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("climateShorthand(%q) = %q, expected %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseTemperatureNeedsUnitSystem(t *testing.T) {
+	caps := entity.Capabilities{EntityID: "climate.living"}
+
+	if _, err := parseTemperature(caps, "21C", ""); err == nil {
+		t.Error("expected an error when the unit system is unknown")
+	}
+	if got, err := parseTemperature(caps, "21C", "°C"); err != nil || got != 21 {
+		t.Errorf("expected 21 without conversion, got %g (err=%v)", got, err)
+	}
+}
+
+func TestClimateShorthandRouting(t *testing.T) {
+	thermostat := client.EntityState{
+		EntityID: "climate.bedroom",
+		State:    "off",
+		Attributes: map[string]interface{}{
+			"friendly_name":      "Bedroom Thermostat",
+			"supported_features": float64(entity.ClimateFeatureTargetTemperature),
+			"hvac_modes":         []interface{}{"off", "heat", "cool", "auto", "dry", "fan_only"},
+		},
+	}
+
+	testRouting(t, []client.EntityState{thermostat}, []routingCase{
+		{[]string{"bedroom", "cool", "70"}, "climate", "set_temperature",
+			map[string]interface{}{"entity_id": "climate.bedroom", "temperature": 70.0, "hvac_mode": "cool"}},
+		{[]string{"bedroom", "auto"}, "climate", "set_hvac_mode",
+			map[string]interface{}{"entity_id": "climate.bedroom", "hvac_mode": "auto"}},
+		{[]string{"bedroom", "fan_only"}, "climate", "set_hvac_mode",
+			map[string]interface{}{"entity_id": "climate.bedroom", "hvac_mode": "fan_only"}},
+	})
+}
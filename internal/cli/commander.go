@@ -3,12 +3,15 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/quinncuatro/hass-cli/internal/cache"
 	"github.com/quinncuatro/hass-cli/internal/config"
 	"github.com/quinncuatro/hass-cli/internal/client"
 	"github.com/quinncuatro/hass-cli/internal/entity"
@@ -17,19 +20,100 @@ import (
 
 type Commander struct {
 	config   *config.Config
-	client   *client.HomeAssistantClient
+	client   client.Client
 	resolver *entity.Resolver
+	options  Options
+	cached   *client.CachingClient
+	disk     *cache.DiskCache
+	tracer   *client.Tracer
+	reloads  chan configReload
+
+	// out receives everything commands print, so a parallel script block
+	// can collect each command's output and show it in order.
+	out io.Writer
+	// noPrompt is set for commands in a parallel block, which must not
+	// read stdin side by side.
+	noPrompt bool
 }
 
-func NewCommander(cfg *config.Config) *Commander {
-	haClient := client.New(cfg)
-	return &Commander{
-		config:   cfg,
-		client:   haClient,
-		resolver: entity.NewResolver(cfg, haClient),
+func NewCommander(cfg *config.Config, opts Options) *Commander {
+	commander := &Commander{
+		config:  cfg,
+		options: opts,
+		out:     os.Stdout,
+	}
+
+	var clientOpts []client.Option
+	if opts.Debug {
+		clientOpts = append(clientOpts, client.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+	if opts.TraceFile != "" {
+		tracer, err := client.NewTracer(opts.TraceFile, opts.Version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; continuing without tracing\n", err)
+		} else {
+			commander.tracer = tracer
+			clientOpts = append(clientOpts, client.WithTracer(tracer))
+		}
+	}
+	commander.client = client.New(cfg, clientOpts...)
+
+	if !opts.NoCache && cfg.HomeAssistant.CacheTimeout > 0 {
+		if dir, err := cache.DefaultDir(); err == nil {
+			commander.disk = cache.NewDiskCache(dir, cacheNamespace(cfg, opts.Version), cfg.HomeAssistant.Timeout)
+		}
+		commander.cached = client.NewCachingClient(commander.client, cfg.HomeAssistant.CacheTimeout, commander.disk)
+		commander.client = commander.cached
+	}
+
+	commander.resolver = entity.NewResolver(cfg, commander.client)
+	return commander
+}
+
+// Close waits for background cache refreshes so they are not cut short when
+// the process exits, then finishes the trace file.
+func (c *Commander) Close() {
+	if c.cached != nil {
+		c.cached.Close()
+	}
+	if c.disk != nil {
+		c.disk.Wait()
+	}
+	if err := c.tracer.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
 }
 
+// batchStatesTTL is how long scripts, macros and the shell reuse states when
+// cache_timeout is 0.
+const batchStatesTTL = time.Minute
+
+// reuseStates makes batch commands share one entity list even when the
+// persistent cache is disabled.
+func (c *Commander) reuseStates() {
+	if c.cached != nil {
+		return
+	}
+
+	ttl := c.config.HomeAssistant.CacheTimeout
+	if ttl <= 0 {
+		ttl = batchStatesTTL
+	}
+
+	c.cached = client.NewCachingClient(c.client, ttl, nil)
+	c.client = c.cached
+	c.resolver = entity.NewResolver(c.config, c.client)
+}
+
+func isBuiltinCommand(name string) bool {
+	for _, command := range config.BuiltinCommands {
+		if command == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Commander) Execute(args []string) error {
 	if len(args) == 0 {
 		return c.showHelp()
@@ -45,12 +129,24 @@ func (c *Commander) Execute(args []string) error {
 		return c.handleStatusCommand(commandArgs)
 	case "tui":
 		return c.handleTUICommand(commandArgs)
+	case "shell":
+		return c.handleShellCommand(commandArgs)
 	case "discover":
 		return c.handleDiscoverCommand(commandArgs)
 	case "automation":
 		return c.handleAutomationCommand(commandArgs)
 	case "scene":
 		return c.handleSceneCommand(commandArgs)
+	case "wait":
+		return c.handleWaitCommand(commandArgs)
+	case "run":
+		return c.handleRunCommand(commandArgs)
+	case "completion":
+		return c.handleCompletionCommand(commandArgs)
+	case "cache":
+		return c.handleCacheCommand(commandArgs)
+	case "__complete":
+		return c.handleCompleteCommand(commandArgs)
 	case "debug":
 		return c.handleDebugCommand(commandArgs)
 	case "help", "--help", "-h":
@@ -58,6 +154,9 @@ func (c *Commander) Execute(args []string) error {
 	case "version", "--version", "-v":
 		return c.showVersion()
 	default:
+		if _, ok := c.config.Macros[command]; ok {
+			return c.handleMacroCommand(command, commandArgs)
+		}
 		return c.handleEntityCommand(args)
 	}
 }
@@ -74,6 +173,12 @@ func (c *Commander) handleConfigCommand(args []string) error {
 		return c.showConfig()
 	case "test":
 		return c.testConfig()
+	case "token":
+		return c.handleTokenCommand(args[1:])
+	case "validate":
+		return c.handleValidateCommand(args[1:])
+	case "migrate":
+		return c.handleMigrateCommand(args[1:])
 	default:
 		return fmt.Errorf("unknown config command: %s", args[0])
 	}
@@ -102,14 +207,14 @@ func (c *Commander) handleTUICommand(args []string) error {
 }
 
 func (c *Commander) handleDiscoverCommand(args []string) error {
-	fmt.Println("Discovering Home Assistant instances...")
-	fmt.Println("Checking common URLs:")
-	fmt.Println("  http://homeassistant.local:8123")
-	fmt.Println("  http://hassio.local:8123")
-	fmt.Println("  http://192.168.1.100:8123")
-	fmt.Println("  http://localhost:8123")
-	fmt.Println()
-	fmt.Println("Note: Full network discovery requires additional implementation")
+	fmt.Fprintln(c.out, "Discovering Home Assistant instances...")
+	fmt.Fprintln(c.out, "Checking common URLs:")
+	fmt.Fprintln(c.out, "  http://homeassistant.local:8123")
+	fmt.Fprintln(c.out, "  http://hassio.local:8123")
+	fmt.Fprintln(c.out, "  http://192.168.1.100:8123")
+	fmt.Fprintln(c.out, "  http://localhost:8123")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Note: Full network discovery requires additional implementation")
 	return nil
 }
 
@@ -142,6 +247,8 @@ func (c *Commander) handleDebugCommand(args []string) error {
 	switch args[0] {
 	case "lights":
 		return c.showLightEntities(ctx)
+	case "services":
+		return c.showServices(ctx)
 	case "match":
 		if len(args) < 3 {
 			return fmt.Errorf("usage: debug match <area> <entity-type>")
@@ -163,7 +270,7 @@ func (c *Commander) showAllEntities(ctx context.Context) error {
 		return fmt.Errorf("failed to get states: %w", err)
 	}
 
-	fmt.Printf("Total entities: %d\n\n", len(states))
+	fmt.Fprintf(c.out, "Total entities: %d\n\n", len(states))
 	
 	domainCounts := make(map[string]int)
 	for _, state := range states {
@@ -171,9 +278,9 @@ func (c *Commander) showAllEntities(ctx context.Context) error {
 		domainCounts[domain]++
 	}
 
-	fmt.Println("Entities by domain:")
+	fmt.Fprintln(c.out, "Entities by domain:")
 	for domain, count := range domainCounts {
-		fmt.Printf("  %s: %d\n", domain, count)
+		fmt.Fprintf(c.out, "  %s: %d\n", domain, count)
 	}
 
 	return nil
@@ -185,7 +292,7 @@ func (c *Commander) showLightEntities(ctx context.Context) error {
 		return fmt.Errorf("failed to get states: %w", err)
 	}
 
-	fmt.Println("Light entities:")
+	fmt.Fprintln(c.out, "Light entities:")
 	for _, state := range states {
 		if strings.HasPrefix(state.EntityID, "light.") {
 			friendlyName := state.EntityID
@@ -193,17 +300,17 @@ func (c *Commander) showLightEntities(ctx context.Context) error {
 				friendlyName = name
 			}
 			
-			fmt.Printf("  %s\n", state.EntityID)
-			fmt.Printf("    friendly_name: %s\n", friendlyName)
-			fmt.Printf("    state: %s\n", state.State)
+			fmt.Fprintf(c.out, "  %s\n", state.EntityID)
+			fmt.Fprintf(c.out, "    friendly_name: %s\n", friendlyName)
+			fmt.Fprintf(c.out, "    state: %s\n", state.State)
 			
 			if areaID, ok := state.Attributes["area_id"].(string); ok {
-				fmt.Printf("    area_id: %s\n", areaID)
+				fmt.Fprintf(c.out, "    area_id: %s\n", areaID)
 			} else {
-				fmt.Printf("    area_id: (not set)\n")
+				fmt.Fprintf(c.out, "    area_id: (not set)\n")
 			}
 			
-			fmt.Println()
+			fmt.Fprintln(c.out)
 		}
 	}
 
@@ -216,22 +323,22 @@ func (c *Commander) debugEntityMatching(ctx context.Context, area, entityType st
 		return fmt.Errorf("failed to get states: %w", err)
 	}
 
-	fmt.Printf("Debug matching: area='%s', entityType='%s'\n\n", area, entityType)
+	fmt.Fprintf(c.out, "Debug matching: area='%s', entityType='%s'\n\n", area, entityType)
 
 	matches := c.resolver.DebugFindMatches(states, area, entityType, "")
 	
 	if len(matches) == 0 {
-		fmt.Println("No matches found")
+		fmt.Fprintln(c.out, "No matches found")
 		return nil
 	}
 
-	fmt.Printf("Found %d matches:\n", len(matches))
+	fmt.Fprintf(c.out, "Found %d matches:\n", len(matches))
 	for i, match := range matches {
-		fmt.Printf("%d. %s (score: %.3f)\n", i+1, match.FriendlyName, match.Score)
-		fmt.Printf("   entity_id: %s\n", match.EntityID)
-		fmt.Printf("   domain: %s\n", match.Domain)
-		fmt.Printf("   area: %s\n", match.Area)
-		fmt.Println()
+		fmt.Fprintf(c.out, "%d. %s (score: %.3f)\n", i+1, match.FriendlyName, match.Score)
+		fmt.Fprintf(c.out, "   entity_id: %s\n", match.EntityID)
+		fmt.Fprintf(c.out, "   domain: %s\n", match.Domain)
+		fmt.Fprintf(c.out, "   area: %s\n", match.Area)
+		fmt.Fprintln(c.out)
 	}
 
 	return nil
@@ -248,7 +355,7 @@ func (c *Commander) setFuzzyThreshold(thresholdStr string) error {
 	}
 	
 	c.config.Preferences.FuzzyThreshold = threshold
-	fmt.Printf("Fuzzy threshold set to %.2f\n", threshold)
+	fmt.Fprintf(c.out, "Fuzzy threshold set to %.2f\n", threshold)
 	return nil
 }
 
@@ -256,46 +363,46 @@ func (c *Commander) handleEntityCommand(args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), c.config.HomeAssistant.Timeout)
 	defer cancel()
 
-	if len(args) < 2 {
-		return fmt.Errorf("insufficient arguments for entity command")
+	positional, flags, err := splitFlags(args, "transition", "type")
+	if err != nil {
+		return withExitCode(ExitUsage, err)
 	}
+	transition, _ := flags.get("transition")
+	mediaType, _ := flags.get("type")
 
-	var area, entityType, action, value string
-	
-	if len(args) == 2 {
-		entityType = args[0]
-		action = args[1]
-	} else if len(args) == 3 {
-		area = args[0]
-		entityType = args[1]
-		action = args[2]
-	} else if len(args) >= 4 {
-		area = args[0]
-		entityType = args[1]
-		action = args[2]
-		value = strings.Join(args[3:], " ")
+	area, entityType, action, value, err := parseEntityArgs(positional)
+	if err != nil {
+		return err
 	}
+	area, entityType, action, value = climateShorthand(area, entityType, action, value)
+	entityType = lockShorthand(entityType, action)
 
 	match, err := c.resolver.ResolveEntity(ctx, area, entityType, "")
 	if err != nil {
 		return fmt.Errorf("failed to resolve entity: %w", err)
 	}
 
-	fmt.Printf("🎯 Matched: %s (%s)\n", match.FriendlyName, match.EntityID)
+	fmt.Fprintf(c.out, "🎯 Matched: %s (%s)\n", match.FriendlyName, match.EntityID)
 
-	switch entity.ParseAction(action) {
-	case "turn_on":
-		err = c.client.TurnOnEntity(ctx, match.EntityID)
-	case "turn_off":
-		err = c.client.TurnOffEntity(ctx, match.EntityID)
-	case "toggle":
-		err = c.client.ToggleEntity(ctx, match.EntityID)
-	default:
-		if value != "" {
-			err = c.handleEntityWithValue(ctx, match, action, value)
-		} else {
-			return fmt.Errorf("unsupported action: %s", action)
+	switch service := entity.ParseAction(action); {
+	case match.Domain == "cover":
+		err = c.handleCover(ctx, match.EntityID, action, value, flags.has("yes"))
+	case match.Domain == "lock" || match.Domain == "alarm_control_panel":
+		err = c.handleSecurity(ctx, match.EntityID, action, value, flags.has("yes"))
+	case transition != "" && match.Domain == "light" && (service == "turn_on" || service == "turn_off"):
+		var caps entity.Capabilities
+		if caps, err = c.entityCapabilities(ctx, match.EntityID); err == nil {
+			err = c.switchLight(ctx, caps, service, transition)
 		}
+	case match.Domain == "vacuum" || match.Domain == "lawn_mower":
+		// These have no turn_on or turn_off; on and off start and dock.
+		err = c.handleEntityWithValue(ctx, match, action, value, transition)
+	case service == "turn_on" || service == "turn_off" || service == "toggle":
+		err = c.switchEntity(ctx, match.EntityID, service, flags.has("yes"))
+	case match.Domain == "media_player":
+		err = c.handleMediaPlayer(ctx, match.EntityID, action, value, mediaType)
+	default:
+		err = c.handleEntityWithValue(ctx, match, action, value, transition)
 	}
 
 	if err != nil {
@@ -303,147 +410,85 @@ func (c *Commander) handleEntityCommand(args []string) error {
 	}
 
 	if c.config.Output.Verbosity > 0 {
-		fmt.Printf("Successfully executed %s on %s (%s)\n", action, match.FriendlyName, match.EntityID)
+		fmt.Fprintf(c.out, "Successfully executed %s on %s (%s)\n", action, match.FriendlyName, match.EntityID)
 	} else {
-		fmt.Printf("✓ Turned %s %s (%s)\n", match.FriendlyName, action, match.EntityID)
+		fmt.Fprintf(c.out, "✓ Turned %s %s (%s)\n", match.FriendlyName, action, match.EntityID)
 	}
 
 	return nil
 }
 
-func (c *Commander) handleEntityWithValue(ctx context.Context, match *entity.EntityMatch, action, value string) error {
-	domain := match.Domain
-	
-	switch domain {
-	case "light":
-		return c.handleLightWithValue(ctx, match.EntityID, action, value)
-	case "fan":
-		return c.handleFanWithValue(ctx, match.EntityID, action, value)
-	case "climate":
-		return c.handleClimateWithValue(ctx, match.EntityID, action, value)
-	case "cover":
-		return c.handleCoverWithValue(ctx, match.EntityID, action, value)
-	default:
-		return fmt.Errorf("value-based actions not supported for domain: %s", domain)
+// switchEntity turns an entity on or off or toggles it, asking first when
+// preferences.confirm_destructive lists the service for its domain.
+func (c *Commander) switchEntity(ctx context.Context, entityID, service string, yes bool) error {
+	if err := c.confirmDestructive(client.EntityState{EntityID: entityID}, service, yes); err != nil {
+		return err
 	}
-}
-
-func (c *Commander) handleLightWithValue(ctx context.Context, entityID, action, value string) error {
-	var serviceData map[string]interface{}
 
-	switch strings.ToLower(action) {
-	case "brightness", "bright", "dim":
-		brightness, err := entity.ParseNumericValue(value)
-		if err != nil {
-			return fmt.Errorf("invalid brightness value: %s", value)
-		}
-		if brightness < 0 || brightness > 255 {
-			return fmt.Errorf("brightness must be between 0 and 255")
-		}
-		serviceData = map[string]interface{}{
-			"brightness": int(brightness),
-		}
-	case "color":
-		serviceData = map[string]interface{}{
-			"color_name": value,
-		}
+	switch service {
+	case "turn_on":
+		return c.client.TurnOnEntity(ctx, entityID)
+	case "turn_off":
+		return c.client.TurnOffEntity(ctx, entityID)
 	default:
-		return fmt.Errorf("unsupported light action: %s", action)
+		return c.client.ToggleEntity(ctx, entityID)
 	}
-
-	target := map[string]interface{}{
-		"entity_id": entityID,
-	}
-
-	_, err := c.client.CallService(ctx, "light", "turn_on", target, serviceData)
-	return err
 }
 
-func (c *Commander) handleFanWithValue(ctx context.Context, entityID, action, value string) error {
-	var serviceData map[string]interface{}
-
-	switch strings.ToLower(action) {
-	case "speed", "percentage":
-		speed, err := entity.ParseNumericValue(value)
-		if err != nil {
-			return fmt.Errorf("invalid speed value: %s", value)
-		}
-		if speed < 0 || speed > 100 {
-			return fmt.Errorf("speed must be between 0 and 100")
-		}
-		serviceData = map[string]interface{}{
-			"percentage": int(speed),
-		}
+func parseEntityArgs(args []string) (area, entityType, action, value string, err error) {
+	switch {
+	case len(args) < 2:
+		err = fmt.Errorf("insufficient arguments for entity command")
+	case len(args) == 2:
+		entityType, action = args[0], args[1]
+	case len(args) == 3:
+		area, entityType, action = args[0], args[1], args[2]
 	default:
-		return fmt.Errorf("unsupported fan action: %s", action)
-	}
-
-	target := map[string]interface{}{
-		"entity_id": entityID,
+		area, entityType, action = args[0], args[1], args[2]
+		value = strings.Join(args[3:], " ")
 	}
-
-	_, err := c.client.CallService(ctx, "fan", "set_percentage", target, serviceData)
-	return err
+	return area, entityType, action, value, err
 }
 
-func (c *Commander) handleClimateWithValue(ctx context.Context, entityID, action, value string) error {
-	var serviceData map[string]interface{}
-
-	switch strings.ToLower(action) {
-	case "temp", "temperature":
-		temp, err := entity.ParseNumericValue(value)
-		if err != nil {
-			return fmt.Errorf("invalid temperature value: %s", value)
-		}
-		serviceData = map[string]interface{}{
-			"temperature": temp,
-		}
-	case "mode":
-		serviceData = map[string]interface{}{
-			"hvac_mode": value,
-		}
-	default:
-		return fmt.Errorf("unsupported climate action: %s", action)
-	}
-
-	target := map[string]interface{}{
-		"entity_id": entityID,
-	}
+func (c *Commander) handleEntityWithValue(ctx context.Context, match *entity.EntityMatch, action, value, transition string) error {
+	domain := match.Domain
 
-	serviceName := "set_temperature"
-	if action == "mode" {
-		serviceName = "set_hvac_mode"
+	caps, err := c.entityCapabilities(ctx, match.EntityID)
+	if err != nil {
+		return err
 	}
 
-	_, err := c.client.CallService(ctx, "climate", serviceName, target, serviceData)
-	return err
-}
-
-func (c *Commander) handleCoverWithValue(ctx context.Context, entityID, action, value string) error {
-	var serviceData map[string]interface{}
-
-	switch strings.ToLower(action) {
-	case "position", "pos":
-		position, err := entity.ParseNumericValue(value)
-		if err != nil {
-			return fmt.Errorf("invalid position value: %s", value)
-		}
-		if position < 0 || position > 100 {
-			return fmt.Errorf("position must be between 0 and 100")
-		}
-		serviceData = map[string]interface{}{
-			"position": int(position),
-		}
+	switch domain {
+	case "light":
+		return c.handleLightWithValue(ctx, caps, action, value, transition)
+	case "fan":
+		return c.handleFanWithValue(ctx, caps, action, value)
+	case "climate":
+		return c.handleClimateWithValue(ctx, caps, action, value)
+	case "vacuum":
+		return c.handleVacuumWithValue(ctx, caps, action, value)
+	case "lawn_mower":
+		return c.handleLawnMowerWithValue(ctx, caps, action)
+	case "water_heater":
+		return c.handleWaterHeaterWithValue(ctx, caps, action, value)
+	case "input_number", "input_select", "input_text", "input_datetime", "counter", "timer",
+		"button", "input_button", "number", "select", "text":
+		return c.handleHelperWithValue(ctx, caps, action, value)
 	default:
-		return fmt.Errorf("unsupported cover action: %s", action)
+		if value == "" {
+			return fmt.Errorf("unsupported action: %s", action)
+		}
+		return fmt.Errorf("value-based actions not supported for domain: %s", domain)
 	}
+}
 
-	target := map[string]interface{}{
-		"entity_id": entityID,
+// entityCapabilities reads what an entity supports from its current state.
+func (c *Commander) entityCapabilities(ctx context.Context, entityID string) (entity.Capabilities, error) {
+	state, err := c.client.GetState(ctx, entityID)
+	if err != nil {
+		return entity.Capabilities{}, fmt.Errorf("failed to get state of %s: %w", entityID, err)
 	}
-
-	_, err := c.client.CallService(ctx, "cover", "set_cover_position", target, serviceData)
-	return err
+	return entity.CapabilitiesOf(*state), nil
 }
 
 func (c *Commander) showHelp() error {
@@ -457,117 +502,151 @@ Commands:
   config      Configuration management
   status      Show entity or system status
   tui         Interactive terminal interface
+  shell       Interactive command shell with history and tab completion
   discover    Discover Home Assistant instances
   automation  Trigger automations
   scene       Activate scenes
+  wait        Block until an entity reaches a state
+  run         Run hass commands from a script file (or - for stdin)
+  completion  Generate shell completion for bash, zsh or fish
+  cache       Show or clear the local entity cache
+  <macro>     Run a macro defined under "macros:" in the config (--dry-run to preview)
   help        Show this help message
   version     Show version information
 
+Global Flags:
+  --no-cache           Bypass the local entity cache
+  --debug              Log API requests to stderr (or set HASS_DEBUG=1)
+  --trace-file <path>  Record requests and responses as HAR (.har) or JSON lines
+
 Entity Control Examples:
   hass living lights on              Turn on living room lights
   hass kitchen fan speed 75          Set kitchen fan to 75% speed
   hass bedroom climate temp 72       Set bedroom temperature to 72°F
 
+Wait Examples:
+  hass wait garage door --state closed --timeout 5m
+  hass wait living lights --attr "brightness>200" --for 30s
+
+Script Examples:
+  hass run evening.hass --continue-on-error
+  echo "living lights off" | hass run -
+
 For more information, visit: https://github.com/quinncuatro/hass-cli`
 
-	fmt.Println(help)
+	fmt.Fprintln(c.out, help)
 	return nil
 }
 
 func (c *Commander) showVersion() error {
-	fmt.Println("hass-cli v0.1.0")
-	fmt.Println("A command-line interface for Home Assistant")
-	fmt.Println("https://github.com/quinncuatro/hass-cli")
+	fmt.Fprintln(c.out, "hass-cli v0.1.0")
+	fmt.Fprintln(c.out, "A command-line interface for Home Assistant")
+	fmt.Fprintln(c.out, "https://github.com/quinncuatro/hass-cli")
 	return nil
 }
 
 func (c *Commander) showConfigHelp() error {
 	help := `Config Commands:
-  init    Initialize configuration with setup wizard
-  show    Display current configuration
-  test    Test connection to Home Assistant`
-
-	fmt.Println(help)
+  init      Initialize configuration with setup wizard
+  show      Display current configuration
+  test      Test connection to Home Assistant
+  validate  Check config.yaml (or the given file) for errors and unknown fields
+  migrate   Upgrade config.yaml to the current layout, keeping a backup
+            (--dry-run to only show the changes)
+  token     Manage the access token: set, clear or migrate it out of
+            config.yaml into the keyring (--store keyring|file)`
+
+	fmt.Fprintln(c.out, help)
 	return nil
 }
 
 func (c *Commander) initConfig() error {
-	fmt.Println("Home Assistant CLI Configuration Setup")
-	fmt.Println("=====================================")
-	fmt.Println()
-	fmt.Println("This wizard will help you configure hass-cli to connect to your Home Assistant instance.")
-	fmt.Println()
-	fmt.Println("You'll need:")
-	fmt.Println("1. Your Home Assistant URL (e.g., http://homeassistant.local:8123)")
-	fmt.Println("2. A long-lived access token from Home Assistant")
-	fmt.Println()
-	fmt.Println("To create a token:")
-	fmt.Println("1. Open Home Assistant in your browser")
-	fmt.Println("2. Go to Profile → Security → Long-lived access tokens")
-	fmt.Println("3. Click 'Create Token'")
-	fmt.Println("4. Enter a name like 'CLI Tool'")
-	fmt.Println("5. Copy the token")
-	fmt.Println()
-	fmt.Println("Example configuration file will be created at:")
+	fmt.Fprintln(c.out, "Home Assistant CLI Configuration Setup")
+	fmt.Fprintln(c.out, "=====================================")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "This wizard will help you configure hass-cli to connect to your Home Assistant instance.")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "You'll need:")
+	fmt.Fprintln(c.out, "1. Your Home Assistant URL (e.g., http://homeassistant.local:8123)")
+	fmt.Fprintln(c.out, "2. A long-lived access token from Home Assistant")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "To create a token:")
+	fmt.Fprintln(c.out, "1. Open Home Assistant in your browser")
+	fmt.Fprintln(c.out, "2. Go to Profile → Security → Long-lived access tokens")
+	fmt.Fprintln(c.out, "3. Click 'Create Token'")
+	fmt.Fprintln(c.out, "4. Enter a name like 'CLI Tool'")
+	fmt.Fprintln(c.out, "5. Copy the token")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Example configuration file will be created at:")
 	
 	configDir, _ := os.UserConfigDir()
 	configPath := filepath.Join(configDir, "hass", "config.yaml")
-	fmt.Printf("  %s\n", configPath)
-	fmt.Println()
-	fmt.Println("Note: Interactive configuration setup requires additional implementation")
-	fmt.Println("For now, manually create the config file with:")
-	fmt.Println()
-	fmt.Println("homeassistant:")
-	fmt.Println("  url: \"http://homeassistant.local:8123\"")
-	fmt.Println("  token: \"your-long-lived-access-token\"")
-	fmt.Println("  timeout: \"10s\"")
-	fmt.Println()
-	fmt.Println("aliases:")
-	fmt.Println("  lr: \"living room\"")
-	fmt.Println("  br: \"bedroom\"")
-	fmt.Println()
-	fmt.Println("preferences:")
-	fmt.Println("  fuzzy_threshold: 0.6")
+	fmt.Fprintf(c.out, "  %s\n", configPath)
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Note: Interactive configuration setup requires additional implementation")
+	fmt.Fprintln(c.out, "For now, manually create the config file with:")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "homeassistant:")
+	fmt.Fprintln(c.out, "  url: \"http://homeassistant.local:8123\"")
+	fmt.Fprintln(c.out, "  token: \"your-long-lived-access-token\"")
+	fmt.Fprintln(c.out, "  timeout: \"10s\"")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "aliases:")
+	fmt.Fprintln(c.out, "  lr: \"living room\"")
+	fmt.Fprintln(c.out, "  br: \"bedroom\"")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "preferences:")
+	fmt.Fprintln(c.out, "  fuzzy_threshold: 0.6")
 	
 	return nil
 }
 
 func (c *Commander) showConfig() error {
-	fmt.Println("Current Configuration:")
-	fmt.Println("=====================")
-	fmt.Printf("Home Assistant URL: %s\n", c.config.HomeAssistant.URL)
+	fmt.Fprintln(c.out, "Current Configuration:")
+	fmt.Fprintln(c.out, "=====================")
+	fmt.Fprintf(c.out, "Home Assistant URL: %s\n", c.config.HomeAssistant.URL)
 	
 	if c.config.HomeAssistant.Token != "" {
 		maskedToken := c.config.HomeAssistant.Token[:8] + "***MASKED***"
-		fmt.Printf("Token: %s\n", maskedToken)
+		fmt.Fprintf(c.out, "Token: %s\n", maskedToken)
 	} else {
-		fmt.Println("Token: Not set")
+		fmt.Fprintln(c.out, "Token: Not set")
 	}
+	fmt.Fprintf(c.out, "Token Store: %s\n", c.config.TokenStoreName())
 	
-	fmt.Printf("Timeout: %s\n", c.config.HomeAssistant.Timeout)
-	fmt.Printf("Skip TLS Verify: %t\n", c.config.HomeAssistant.SkipTLSVerify)
-	fmt.Printf("Cache Timeout: %s\n", c.config.HomeAssistant.CacheTimeout)
-	fmt.Println()
+	fmt.Fprintf(c.out, "Timeout: %s\n", c.config.HomeAssistant.Timeout)
+	fmt.Fprintf(c.out, "Skip TLS Verify: %t\n", c.config.HomeAssistant.SkipTLSVerify)
+	fmt.Fprintf(c.out, "Cache Timeout: %s\n", c.config.HomeAssistant.CacheTimeout)
+	fmt.Fprintf(c.out, "Retries: %d (backoff %s)\n", c.config.HomeAssistant.Retries, c.config.HomeAssistant.RetryBackoff)
+	fmt.Fprintln(c.out)
 	
 	if len(c.config.Aliases) > 0 {
-		fmt.Println("Aliases:")
+		fmt.Fprintln(c.out, "Aliases:")
 		for alias, target := range c.config.Aliases {
-			fmt.Printf("  %s → %s\n", alias, target)
+			fmt.Fprintf(c.out, "  %s → %s\n", alias, target)
 		}
-		fmt.Println()
+		fmt.Fprintln(c.out)
 	}
 	
-	fmt.Printf("Output Format: %s\n", c.config.Output.Format)
-	fmt.Printf("Color Output: %t\n", c.config.Output.Color)
-	fmt.Printf("Verbosity: %d\n", c.config.Output.Verbosity)
-	fmt.Printf("Fuzzy Threshold: %.2f\n", c.config.Preferences.FuzzyThreshold)
+	if len(c.config.Macros) > 0 {
+		fmt.Fprintln(c.out, "Macros:")
+		for name, steps := range c.config.Macros {
+			fmt.Fprintf(c.out, "  %s → %s\n", name, strings.Join(steps, "; "))
+		}
+		fmt.Fprintln(c.out)
+	}
+
+	fmt.Fprintf(c.out, "Output Format: %s\n", c.config.Output.Format)
+	fmt.Fprintf(c.out, "Color Output: %t\n", c.config.Output.Color)
+	fmt.Fprintf(c.out, "Verbosity: %d\n", c.config.Output.Verbosity)
+	fmt.Fprintf(c.out, "Fuzzy Threshold: %.2f\n", c.config.Preferences.FuzzyThreshold)
 	
 	return nil
 }
 
 func (c *Commander) testConfig() error {
-	fmt.Println("Testing Home Assistant Connection...")
-	fmt.Println("===================================")
+	fmt.Fprintln(c.out, "Testing Home Assistant Connection...")
+	fmt.Fprintln(c.out, "===================================")
 	
 	if c.config.HomeAssistant.URL == "" {
 		return fmt.Errorf("❌ Home Assistant URL not configured")
@@ -577,31 +656,31 @@ func (c *Commander) testConfig() error {
 		return fmt.Errorf("❌ Home Assistant token not configured")
 	}
 	
-	fmt.Printf("✓ Configuration file found\n")
-	fmt.Printf("✓ URL configured: %s\n", c.config.HomeAssistant.URL)
-	fmt.Printf("✓ Token configured\n")
+	fmt.Fprintf(c.out, "✓ Configuration file found\n")
+	fmt.Fprintf(c.out, "✓ URL configured: %s\n", c.config.HomeAssistant.URL)
+	fmt.Fprintf(c.out, "✓ Token configured\n")
 	
 	ctx, cancel := context.WithTimeout(context.Background(), c.config.HomeAssistant.Timeout)
 	defer cancel()
 	
-	fmt.Println("Testing connection...")
+	fmt.Fprintln(c.out, "Testing connection...")
 	
 	status, err := c.client.GetSystemStatus(ctx)
 	if err != nil {
 		return fmt.Errorf("❌ Connection failed: %w", err)
 	}
 	
-	fmt.Printf("✓ Connection successful\n")
-	fmt.Printf("✓ Authentication valid\n")
-	fmt.Printf("✓ Home Assistant version: %s\n", status.Version)
-	fmt.Printf("✓ Location: %s\n", status.LocationName)
+	fmt.Fprintf(c.out, "✓ Connection successful\n")
+	fmt.Fprintf(c.out, "✓ Authentication valid\n")
+	fmt.Fprintf(c.out, "✓ Home Assistant version: %s\n", status.Version)
+	fmt.Fprintf(c.out, "✓ Location: %s\n", status.LocationName)
 	
 	states, err := c.client.GetStates(ctx)
 	if err != nil {
 		return fmt.Errorf("❌ Failed to fetch entities: %w", err)
 	}
 	
-	fmt.Printf("✓ Found %d entities\n", len(states))
+	fmt.Fprintf(c.out, "✓ Found %d entities\n", len(states))
 	
 	domainCounts := make(map[string]int)
 	for _, state := range states {
@@ -609,12 +688,12 @@ func (c *Commander) testConfig() error {
 		domainCounts[domain]++
 	}
 	
-	fmt.Println("\nEntity breakdown:")
+	fmt.Fprintln(c.out, "\nEntity breakdown:")
 	for domain, count := range domainCounts {
-		fmt.Printf("  %s: %d\n", domain, count)
+		fmt.Fprintf(c.out, "  %s: %d\n", domain, count)
 	}
 	
-	fmt.Println("\n✅ All tests passed! Your configuration is working correctly.")
+	fmt.Fprintln(c.out, "\n✅ All tests passed! Your configuration is working correctly.")
 	
 	return nil
 }
@@ -632,17 +711,17 @@ func (c *Commander) showSystemStatus() error {
 		return fmt.Errorf("failed to get system status: %w", err)
 	}
 
-	fmt.Printf("Home Assistant Status:\n")
-	fmt.Printf("  Version: %s\n", status.Version)
-	fmt.Printf("  State: %s\n", status.State)
-	fmt.Printf("  Location: %s\n", status.LocationName)
-	fmt.Printf("  Timezone: %s\n", status.Timezone)
-	fmt.Printf("  Unit System: Temperature: %s, Length: %s, Mass: %s, Volume: %s\n", 
+	fmt.Fprintf(c.out, "Home Assistant Status:\n")
+	fmt.Fprintf(c.out, "  Version: %s\n", status.Version)
+	fmt.Fprintf(c.out, "  State: %s\n", status.State)
+	fmt.Fprintf(c.out, "  Location: %s\n", status.LocationName)
+	fmt.Fprintf(c.out, "  Timezone: %s\n", status.Timezone)
+	fmt.Fprintf(c.out, "  Unit System: Temperature: %s, Length: %s, Mass: %s, Volume: %s\n", 
 		status.UnitSystem.Temperature, status.UnitSystem.Length, status.UnitSystem.Mass, status.UnitSystem.Volume)
-	fmt.Printf("  External URL: %s\n", status.ExternalURL)
-	fmt.Printf("  Internal URL: %s\n", status.InternalURL)
-	fmt.Printf("  Safe Mode: %t\n", status.SafeMode)
-	fmt.Printf("  Recovery Mode: %t\n", status.RecoveryMode)
+	fmt.Fprintf(c.out, "  External URL: %s\n", status.ExternalURL)
+	fmt.Fprintf(c.out, "  Internal URL: %s\n", status.InternalURL)
+	fmt.Fprintf(c.out, "  Safe Mode: %t\n", status.SafeMode)
+	fmt.Fprintf(c.out, "  Recovery Mode: %t\n", status.RecoveryMode)
 
 	return nil
 }
@@ -652,12 +731,12 @@ func (c *Commander) showEntityStatus(query string) error {
 	defer cancel()
 
 	if query == "" {
-		states, err := c.client.GetStates(ctx)
+		states, err := client.Uncached(c.client).GetStates(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to get entity states: %w", err)
 		}
 
-		fmt.Printf("Total entities: %d\n\n", len(states))
+		fmt.Fprintf(c.out, "Total entities: %d\n\n", len(states))
 		
 		domainCounts := make(map[string]int)
 		for _, state := range states {
@@ -665,9 +744,9 @@ func (c *Commander) showEntityStatus(query string) error {
 			domainCounts[domain]++
 		}
 
-		fmt.Println("Entities by domain:")
+		fmt.Fprintln(c.out, "Entities by domain:")
 		for domain, count := range domainCounts {
-			fmt.Printf("  %s: %d\n", domain, count)
+			fmt.Fprintf(c.out, "  %s: %d\n", domain, count)
 		}
 
 		return nil
@@ -678,30 +757,73 @@ func (c *Commander) showEntityStatus(query string) error {
 		return fmt.Errorf("failed to resolve entity: %w", err)
 	}
 
-	state, err := c.client.GetState(ctx, match.EntityID)
+	// Status shows the state as it is now, not as cached.
+	state, err := client.Uncached(c.client).GetState(ctx, match.EntityID)
 	if err != nil {
 		return fmt.Errorf("failed to get entity state: %w", err)
 	}
 
-	fmt.Printf("Entity: %s (%s)\n", match.FriendlyName, match.EntityID)
-	fmt.Printf("State: %s\n", state.State)
-	fmt.Printf("Domain: %s\n", match.Domain)
+	fmt.Fprintf(c.out, "Entity: %s (%s)\n", match.FriendlyName, match.EntityID)
+	fmt.Fprintf(c.out, "State: %s\n", state.State)
+	fmt.Fprintf(c.out, "Domain: %s\n", match.Domain)
 	if match.Area != "" {
-		fmt.Printf("Area: %s\n", match.Area)
+		fmt.Fprintf(c.out, "Area: %s\n", match.Area)
+	}
+	fmt.Fprintf(c.out, "Last Changed: %s\n", state.LastChanged.Format(time.RFC3339))
+	fmt.Fprintf(c.out, "Last Updated: %s\n", state.LastUpdated.Format(time.RFC3339))
+
+	switch match.Domain {
+	case "media_player":
+		printNowPlaying(c.out, *state)
+	case "vacuum", "lawn_mower", "water_heater":
+		c.printApplianceStatus(ctx, *state)
 	}
-	fmt.Printf("Last Changed: %s\n", state.LastChanged.Format(time.RFC3339))
-	fmt.Printf("Last Updated: %s\n", state.LastUpdated.Format(time.RFC3339))
 
 	if c.config.Output.Verbosity > 1 {
-		fmt.Println("\nAttributes:")
+		fmt.Fprintln(c.out, "\nAttributes:")
 		for key, value := range state.Attributes {
-			fmt.Printf("  %s: %v\n", key, value)
+			fmt.Fprintf(c.out, "  %s: %v\n", key, value)
 		}
 	}
 
 	return nil
 }
 
+// printApplianceStatus shows the battery level and details of a vacuum,
+// lawn mower or water heater in entity status. A battery not reported as
+// battery_level is looked for in the sensor.<name>_battery entity that
+// integrations commonly create alongside the device.
+func (c *Commander) printApplianceStatus(ctx context.Context, state client.EntityState) {
+	var details [][2]string
+
+	if level, ok := state.Attributes["battery_level"].(float64); ok {
+		details = append(details, [2]string{"Battery", fmt.Sprintf("%.0f%%", level)})
+	} else {
+		objectID := strings.SplitN(state.EntityID, ".", 2)[1]
+		if battery, err := client.Uncached(c.client).GetState(ctx, "sensor."+objectID+"_battery"); err == nil {
+			unit, _ := battery.Attributes["unit_of_measurement"].(string)
+			details = append(details, [2]string{"Battery", battery.State + unit})
+		}
+	}
+
+	for _, attribute := range [][2]string{
+		{"Status", "status"},
+		{"Fan Speed", "fan_speed"},
+		{"Current Temperature", "current_temperature"},
+		{"Target Temperature", "temperature"},
+		{"Operation Mode", "operation_mode"},
+		{"Away Mode", "away_mode"},
+	} {
+		if value, ok := state.Attributes[attribute[1]]; ok && value != nil && value != "" {
+			details = append(details, [2]string{attribute[0], fmt.Sprint(value)})
+		}
+	}
+
+	for _, detail := range details {
+		fmt.Fprintf(c.out, "%s: %s\n", detail[0], detail[1])
+	}
+}
+
 func (c *Commander) listAutomations() error {
 	ctx, cancel := context.WithTimeout(context.Background(), c.config.HomeAssistant.Timeout)
 	defer cancel()
@@ -711,7 +833,7 @@ func (c *Commander) listAutomations() error {
 		return fmt.Errorf("failed to get states: %w", err)
 	}
 
-	fmt.Println("Available Automations:")
+	fmt.Fprintln(c.out, "Available Automations:")
 	found := false
 	for _, state := range states {
 		if strings.HasPrefix(state.EntityID, "automation.") {
@@ -723,13 +845,13 @@ func (c *Commander) listAutomations() error {
 			if state.State == "off" {
 				status = "disabled"
 			}
-			fmt.Printf("  %s (%s) - %s\n", friendlyName, state.EntityID, status)
+			fmt.Fprintf(c.out, "  %s (%s) - %s\n", friendlyName, state.EntityID, status)
 			found = true
 		}
 	}
 
 	if !found {
-		fmt.Println("  No automations found")
+		fmt.Fprintln(c.out, "  No automations found")
 	}
 
 	return nil
@@ -777,7 +899,7 @@ func (c *Commander) triggerAutomation(name string) error {
 	}
 
 	if c.config.Output.Verbosity > 0 {
-		fmt.Printf("Successfully triggered automation: %s\n", automationID)
+		fmt.Fprintf(c.out, "Successfully triggered automation: %s\n", automationID)
 	}
 
 	return nil
@@ -792,7 +914,7 @@ func (c *Commander) listScenes() error {
 		return fmt.Errorf("failed to get states: %w", err)
 	}
 
-	fmt.Println("Available Scenes:")
+	fmt.Fprintln(c.out, "Available Scenes:")
 	found := false
 	for _, state := range states {
 		if strings.HasPrefix(state.EntityID, "scene.") {
@@ -800,13 +922,13 @@ func (c *Commander) listScenes() error {
 			if name, ok := state.Attributes["friendly_name"].(string); ok {
 				friendlyName = name
 			}
-			fmt.Printf("  %s (%s)\n", friendlyName, state.EntityID)
+			fmt.Fprintf(c.out, "  %s (%s)\n", friendlyName, state.EntityID)
 			found = true
 		}
 	}
 
 	if !found {
-		fmt.Println("  No scenes found")
+		fmt.Fprintln(c.out, "  No scenes found")
 	}
 
 	return nil
@@ -854,7 +976,7 @@ func (c *Commander) activateScene(name string) error {
 	}
 
 	if c.config.Output.Verbosity > 0 {
-		fmt.Printf("Successfully activated scene: %s\n", sceneID)
+		fmt.Fprintf(c.out, "Successfully activated scene: %s\n", sceneID)
 	}
 
 	return nil
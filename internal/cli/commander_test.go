@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/config"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+// serviceCall is a service call received by the fake Home Assistant, with
+// its target and service data merged.
+type serviceCall struct {
+	Domain  string
+	Service string
+	Data    map[string]interface{}
+}
+
+// fakeHomeAssistant records the service calls a test Commander makes.
+type fakeHomeAssistant struct {
+	mu    sync.Mutex
+	calls []serviceCall
+	// statesFetches counts requests for every state.
+	statesFetches int
+}
+
+// lastCall returns the most recent service call, failing the test if none
+// was made.
+func (f *fakeHomeAssistant) lastCall(t *testing.T) serviceCall {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.calls) == 0 {
+		t.Fatal("expected a service call, got none")
+	}
+	return f.calls[len(f.calls)-1]
+}
+
+// callCount returns how many service calls were made.
+func (f *fakeHomeAssistant) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.calls)
+}
+
+func (f *fakeHomeAssistant) record(r *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	call := serviceCall{Data: map[string]interface{}{}}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/services/"), "/")
+	if len(parts) == 2 {
+		call.Domain, call.Service = parts[0], parts[1]
+	}
+
+	// CallService wraps its data, TurnOnEntity and friends send it flat.
+	target, wrapped := body["target"].(map[string]interface{})
+	data, _ := body["service_data"].(map[string]interface{})
+	if !wrapped && data == nil {
+		data = body
+	}
+	for _, values := range []map[string]interface{}{target, data} {
+		for key, value := range values {
+			call.Data[key] = value
+		}
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+}
+
+// newTestCommander returns a Commander talking to a fake Home Assistant that
+// serves the given states.
+func newTestCommander(t *testing.T, states ...client.EntityState) *Commander {
+	t.Helper()
+
+	commander, _ := newRecordingCommander(t, states...)
+	return commander
+}
+
+// newRecordingCommander is newTestCommander, also returning the fake Home
+// Assistant so tests can check the service calls made. Its unit system is
+// imperial.
+func newRecordingCommander(t *testing.T, states ...client.EntityState) (*Commander, *fakeHomeAssistant) {
+	t.Helper()
+
+	fake := &fakeHomeAssistant{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/api/states":
+			fake.mu.Lock()
+			fake.statesFetches++
+			fake.mu.Unlock()
+			_ = json.NewEncoder(w).Encode(states)
+			return
+		case r.URL.Path == "/api/config":
+			_, _ = w.Write([]byte(`{"unit_system": {"temperature": "°F"}}`))
+			return
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/services/"):
+			fake.record(r)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+
+		entityID := strings.TrimPrefix(r.URL.Path, "/api/states/")
+		for _, state := range states {
+			if state.EntityID == entityID {
+				_ = json.NewEncoder(w).Encode(state)
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.HomeAssistant.URL = server.URL
+	cfg.HomeAssistant.Token = "test-token"
+	cfg.HomeAssistant.Timeout = 5 * time.Second
+	cfg.Output.Verbosity = 0
+
+	return NewCommander(cfg, Options{NoCache: true}), fake
+}
+
+// routingCase is a command and the service call it should send, with the
+// target and service data merged as serviceCall holds them.
+type routingCase struct {
+	args    []string
+	domain  string
+	service string
+	data    map[string]interface{}
+}
+
+// testRouting runs each command through Execute against a fake Home
+// Assistant serving states and checks the service call it sent.
+func testRouting(t *testing.T, states []client.EntityState, tests []routingCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			commander, fake := newRecordingCommander(t, states...)
+			if err := commander.Execute(tt.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			call := fake.lastCall(t)
+			if call.Domain != tt.domain || call.Service != tt.service || !reflect.DeepEqual(call.Data, tt.data) {
+				t.Errorf("expected %s.%s %v, got %s.%s %v", tt.domain, tt.service, tt.data, call.Domain, call.Service, call.Data)
+			}
+		})
+	}
+}
+
+func TestNeedsToken(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"__complete", "liv"}, false},
+		{[]string{"help"}, false},
+		{[]string{"version"}, false},
+		{[]string{"config", "token", "set"}, false},
+		{[]string{"config", "test"}, true},
+		{[]string{"status"}, true},
+		{[]string{"living", "lights", "on"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := NeedsToken(tt.args); got != tt.want {
+			t.Errorf("NeedsToken(%q) = %v, expected %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	t.Setenv("HASS_DEBUG", "")
+
+	opts, args, err := ParseGlobalFlags([]string{"status", "--no-cache", "--trace-file", "out.har", "living", "--debug", "--", "--no-cache"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.NoCache || !opts.Debug || opts.TraceFile != "out.har" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if strings.Join(args, " ") != "status living -- --no-cache" {
+		t.Errorf("unexpected remaining args: %v", args)
+	}
+
+	if _, _, err := ParseGlobalFlags([]string{"--trace-file"}); ExitCode(err) != ExitUsage {
+		t.Errorf("expected usage error for missing trace path, got %v", err)
+	}
+
+	t.Setenv("HASS_DEBUG", "1")
+	if opts, _, _ := ParseGlobalFlags(nil); !opts.Debug {
+		t.Error("expected HASS_DEBUG to enable debug logging")
+	}
+}
+
+func TestEntityCommandChecksCapabilities(t *testing.T) {
+	commander := newTestCommander(t, client.EntityState{
+		EntityID: "light.porch",
+		State:    "off",
+		Attributes: map[string]interface{}{
+			"friendly_name":         "Porch Light",
+			"supported_features":    0,
+			"supported_color_modes": []string{"onoff"},
+		},
+	})
+
+	err := commander.Execute([]string{"porch", "light", "brightness", "128"})
+	if !errors.Is(err, entity.ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "supported: on, off, toggle") {
+		t.Errorf("expected supported actions to be suggested, got %v", err)
+	}
+}
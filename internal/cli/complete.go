@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/config"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+var configSubcommands = []string{"init", "migrate", "show", "test", "token", "validate"}
+
+var valueActionWords = []string{
+	"brightness", "bright", "dim", "color", "speed", "percentage", "temp", "temperature", "mode",
+	"position", "pos", "warm", "cool", "kelvin", "rgb", "effect", "flash", "faster", "slower",
+	"oscillate", "direction", "preset", "heat", "range", "fan", "swing", "humidity",
+	"stop", "tilt", "lock", "unlock", "arm", "disarm",
+	"play", "pause", "next", "previous", "volume", "mute", "source", "shuffle", "repeat", "join", "unjoin",
+	"start", "dock", "locate", "fan_speed", "segment", "away",
+	"set", "select", "increment", "decrement", "reset", "clear", "cancel", "finish", "press",
+}
+
+// completions returns the candidates for the word being typed after the
+// already completed words in args.
+func (c *Commander) completions(args []string, prefix string, states func() []client.EntityState) []string {
+	var candidates []string
+
+	switch {
+	case len(args) == 0:
+		candidates = append(candidates, config.BuiltinCommands...)
+		for name := range c.config.Macros {
+			candidates = append(candidates, name)
+		}
+		for alias := range c.config.Aliases {
+			candidates = append(candidates, alias)
+		}
+		candidates = append(candidates, entity.EntityTypeKeywords()...)
+		candidates = append(candidates, areaWords(states())...)
+	case args[0] == "config":
+		if len(args) == 1 {
+			candidates = configSubcommands
+		}
+	case args[0] == "completion":
+		if len(args) == 1 {
+			candidates = []string{"bash", "zsh", "fish"}
+		}
+	case args[0] == "cache":
+		if len(args) == 1 {
+			candidates = []string{"show", "clear"}
+		}
+	case args[0] == "scene" || args[0] == "automation":
+		candidates = nameWords(states(), args[0])
+	case args[0] == "status" || args[0] == "wait":
+		candidates = nameWords(states(), "")
+	case isBuiltinCommand(args[0]):
+		return nil
+	case len(args) == 1:
+		candidates = append(candidates, entity.EntityTypeKeywords()...)
+		candidates = append(candidates, nameWords(states(), "")...)
+	default:
+		candidates = append(candidates, entity.ActionKeywords()...)
+		candidates = append(candidates, valueActionWords...)
+	}
+
+	return filterCandidates(candidates, prefix)
+}
+
+// areaWords lists area ids plus the leading word of each friendly name, which
+// is how most installations prefix entities with their room.
+func areaWords(states []client.EntityState) []string {
+	var words []string
+	for _, state := range states {
+		if areaID, ok := state.Attributes["area_id"].(string); ok && areaID != "" {
+			words = append(words, strings.ToLower(areaID))
+		}
+		if name, ok := state.Attributes["friendly_name"].(string); ok {
+			if fields := strings.Fields(strings.ToLower(name)); len(fields) > 1 {
+				words = append(words, fields[0])
+			}
+		}
+	}
+	return words
+}
+
+func nameWords(states []client.EntityState, domain string) []string {
+	var words []string
+	for _, state := range states {
+		if domain != "" && !strings.HasPrefix(state.EntityID, domain+".") {
+			continue
+		}
+		if name, ok := state.Attributes["friendly_name"].(string); ok {
+			words = append(words, strings.Fields(strings.ToLower(name))...)
+		}
+	}
+	return words
+}
+
+func filterCandidates(candidates []string, prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	seen := make(map[string]bool)
+
+	var result []string
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(strings.ToLower(candidate), prefix) {
+			continue
+		}
+		seen[candidate] = true
+		result = append(result, candidate)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+func commonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+// completionRefreshTimeout bounds how long a tab press may wait on Home
+// Assistant when the entity cache is missing.
+const completionRefreshTimeout = 2 * time.Second
+
+const bashCompletion = `# bash completion for hass
+_hass_completion() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local IFS=$'\n'
+    COMPREPLY=($(hass __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" 2>/dev/null))
+}
+complete -o default -F _hass_completion hass
+`
+
+const zshCompletion = `#compdef hass
+# zsh completion for hass
+_hass() {
+    local -a candidates
+    candidates=("${(@f)$(hass __complete "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${candidates[1]}" ]]; then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+
+if [[ "${funcstack[1]}" == "_hass" ]]; then
+    _hass "$@"
+else
+    compdef _hass hass
+fi
+`
+
+const fishCompletion = `# fish completion for hass
+function __hass_complete
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    hass __complete $tokens (commandline -ct) 2>/dev/null
+end
+
+complete -c hass -f -a '(__hass_complete)'
+complete -c hass -n '__fish_seen_subcommand_from run' -F
+`
+
+func (c *Commander) handleCompletionCommand(args []string) error {
+	if len(args) != 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("usage: completion bash|zsh|fish"))
+	}
+
+	switch args[0] {
+	case "bash":
+		fmt.Fprint(c.out, bashCompletion)
+	case "zsh":
+		fmt.Fprint(c.out, zshCompletion)
+	case "fish":
+		fmt.Fprint(c.out, fishCompletion)
+	default:
+		return withExitCode(ExitUsage, fmt.Errorf("unsupported shell: %s (expected bash, zsh or fish)", args[0]))
+	}
+
+	return nil
+}
+
+// handleCompleteCommand backs the generated completion scripts. The last
+// argument is the word being completed; candidates are printed one per line.
+// Errors are swallowed so a broken connection never spams the prompt.
+func (c *Commander) handleCompleteCommand(args []string) error {
+	prefix := ""
+	if len(args) > 0 {
+		prefix = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	for _, candidate := range c.completions(args, prefix, c.loadCompletionStates) {
+		fmt.Fprintln(c.out, candidate)
+	}
+
+	return nil
+}
+
+// loadCompletionStates serves entities through the resolver, which reads
+// the on-disk cache and refreshes stale entries in the background.
+func (c *Commander) loadCompletionStates() []client.EntityState {
+	if c.config.HomeAssistant.URL == "" || c.config.HomeAssistant.Token == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionRefreshTimeout)
+	defer cancel()
+
+	states, err := c.resolver.States(ctx)
+	if err != nil {
+		return nil
+	}
+	return states
+}
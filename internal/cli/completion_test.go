@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/cache"
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func TestLoadCompletionStatesUsesDiskCache(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	commander := newTestCommander(t, client.EntityState{
+		EntityID: "light.living_room_lamp",
+		State:    "on",
+		Attributes: map[string]interface{}{
+			"friendly_name": "Living Room Lamp",
+		},
+	})
+	useDiskCache(commander, cacheDir)
+
+	states := commander.loadCompletionStates()
+	if len(states) != 1 || states[0].Attributes["friendly_name"] != "Living Room Lamp" {
+		t.Fatalf("unexpected states: %+v", states)
+	}
+
+	info, err := os.Stat(filepath.Join(cacheDir, "states.json"))
+	if err != nil {
+		t.Fatalf("expected entity cache to be written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected cache permissions 0600, got %o", info.Mode().Perm())
+	}
+
+	// A fresh cache is served without contacting Home Assistant.
+	offline := newTestCommander(t)
+	offline.config.HomeAssistant.URL = "http://127.0.0.1:1"
+	offline.client = client.New(offline.config)
+	useDiskCache(offline, cacheDir)
+	if states := offline.loadCompletionStates(); len(states) != 1 {
+		t.Errorf("expected cached states, got %+v", states)
+	}
+}
+
+func useDiskCache(commander *Commander, dir string) {
+	commander.disk = cache.NewDiskCache(dir, "test", time.Second)
+	commander.cached = client.NewCachingClient(commander.client, time.Minute, commander.disk)
+	commander.client = commander.cached
+	commander.resolver = entity.NewResolver(commander.config, commander.client)
+}
+
+func TestHandleCompletionCommand(t *testing.T) {
+	commander := newTestCommander(t)
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		if err := commander.Execute([]string{"completion", shell}); err != nil {
+			t.Errorf("completion %s: unexpected error: %v", shell, err)
+		}
+	}
+
+	if code := ExitCode(commander.Execute([]string{"completion", "powershell"})); code != ExitUsage {
+		t.Errorf("expected usage error for unsupported shell, got exit code %d", code)
+	}
+}
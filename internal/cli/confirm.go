@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// errNotConfirmed is returned when an action was declined or could not be
+// confirmed.
+var errNotConfirmed = errors.New("not confirmed")
+
+// confirmAction asks before doing something that is hard to undo, described
+// by action, unless yes is set. Without a terminal there is nobody to ask, so
+// --yes is required.
+func confirmAction(action string, yes bool) error {
+	if yes {
+		return nil
+	}
+
+	refused := withExitCode(ExitUsage, fmt.Errorf("%s %w; pass --yes to skip the prompt", action, errNotConfirmed))
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return refused
+	}
+
+	fmt.Fprintf(os.Stderr, "Really %s? [y/N] ", action)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("failed to read answer: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return nil
+	default:
+		return refused
+	}
+}
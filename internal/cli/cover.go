@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+// handleCover runs any cover action. Covers have their own services rather
+// than turn_on and turn_off.
+func (c *Commander) handleCover(ctx context.Context, entityID, action, value string, yes bool) error {
+	// Relative moves, toggle and the garage door check need the current
+	// position, not a cached one.
+	state, err := client.Uncached(c.client).GetState(ctx, entityID)
+	if err != nil {
+		return fmt.Errorf("failed to get state of %s: %w", entityID, err)
+	}
+
+	service, serviceData, err := coverServiceData(*state, strings.ToLower(action), value)
+	if err != nil {
+		return err
+	}
+
+	// Garage doors ask before opening by default, however they are opened.
+	if opensCover(*state, service, serviceData) {
+		if err := c.confirmDestructive(*state, "open_cover", yes); err != nil {
+			return err
+		}
+	}
+
+	target := map[string]interface{}{
+		"entity_id": entityID,
+	}
+
+	_, err = c.client.CallService(ctx, "cover", service, target, serviceData)
+	return err
+}
+
+// coverServiceData maps an action and optional value to a cover service call:
+//
+//	open, close, stop, toggle      (on and off also open and close)
+//	50, position 50                position in percent
+//	+20, -10, position +20         move relative to the current position
+//	tilt 45, tilt +10              tilt position
+//	tilt open|close|stop           also open_tilt, close_tilt, stop_tilt
+func coverServiceData(state client.EntityState, action, value string) (string, map[string]interface{}, error) {
+	caps := entity.CapabilitiesOf(state)
+	none := map[string]interface{}{}
+
+	switch entity.ParseAction(action) {
+	case "turn_on":
+		if !caps.Has(entity.CoverFeatureOpen) {
+			return "", nil, caps.Unsupported("open", "")
+		}
+		return "open_cover", none, nil
+	case "turn_off":
+		if !caps.Has(entity.CoverFeatureClose) {
+			return "", nil, caps.Unsupported("close", "")
+		}
+		return "close_cover", none, nil
+	case "toggle":
+		return "toggle", none, nil
+	}
+
+	switch action {
+	case "stop":
+		if !caps.Has(entity.CoverFeatureStop) {
+			return "", nil, caps.Unsupported("stop", "it cannot be stopped while moving")
+		}
+		return "stop_cover", none, nil
+
+	case "position", "pos":
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		return coverPositionData(caps, state, value)
+
+	case "tilt":
+		switch strings.ToLower(value) {
+		case "":
+			return "", nil, fmt.Errorf("tilt needs a value")
+		case "open", "close", "stop":
+			return coverServiceData(state, strings.ToLower(value)+"_tilt", "")
+		}
+		if !caps.Has(entity.CoverFeatureSetTiltPosition) {
+			return "", nil, caps.Unsupported("tilt", "it cannot set a tilt position")
+		}
+		tilt, err := coverTarget(state, "current_tilt_position", value)
+		if err != nil {
+			return "", nil, err
+		}
+		return "set_cover_tilt_position", map[string]interface{}{"tilt_position": tilt}, nil
+
+	case "open_tilt":
+		if !caps.Has(entity.CoverFeatureOpenTilt) {
+			return "", nil, caps.Unsupported("tilt open", "it cannot tilt")
+		}
+		return "open_cover_tilt", none, nil
+	case "close_tilt":
+		if !caps.Has(entity.CoverFeatureCloseTilt) {
+			return "", nil, caps.Unsupported("tilt close", "it cannot tilt")
+		}
+		return "close_cover_tilt", none, nil
+	case "stop_tilt":
+		if !caps.Has(entity.CoverFeatureStopTilt) {
+			return "", nil, caps.Unsupported("tilt stop", "it cannot stop tilting")
+		}
+		return "stop_cover_tilt", none, nil
+	}
+
+	// "hass bedroom curtains 50" and "hass bedroom curtains +20".
+	if value == "" {
+		if _, err := strconv.ParseFloat(strings.TrimSuffix(action, "%"), 64); err == nil {
+			return coverPositionData(caps, state, action)
+		}
+	}
+
+	return "", nil, caps.Unsupported(action, "")
+}
+
+func coverPositionData(caps entity.Capabilities, state client.EntityState, value string) (string, map[string]interface{}, error) {
+	if !caps.Has(entity.CoverFeatureSetPosition) {
+		return "", nil, caps.Unsupported("position", "it can only open and close")
+	}
+	position, err := coverTarget(state, "current_position", value)
+	if err != nil {
+		return "", nil, err
+	}
+	return "set_cover_position", map[string]interface{}{"position": position}, nil
+}
+
+// coverTarget reads an absolute position or a move relative to the current
+// value of attribute, clamped to 0-100.
+func coverTarget(state client.EntityState, attribute, value string) (int, error) {
+	raw := strings.TrimSuffix(strings.TrimSpace(value), "%")
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid position value: %s", value)
+	}
+
+	if !strings.HasPrefix(raw, "+") && !strings.HasPrefix(raw, "-") {
+		if number < 0 || number > 100 {
+			return 0, fmt.Errorf("position must be between 0 and 100")
+		}
+		return int(math.Round(number)), nil
+	}
+
+	current, ok := state.Attributes[attribute].(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s does not report its %s; use an absolute value", state.EntityID, strings.ReplaceAll(attribute, "_", " "))
+	}
+	return int(math.Round(math.Max(0, math.Min(100, current+number)))), nil
+}
+
+// opensCover reports whether a service call may open the cover further.
+func opensCover(state client.EntityState, service string, serviceData map[string]interface{}) bool {
+	switch service {
+	case "open_cover":
+		return true
+	case "toggle":
+		return state.State != "open" && state.State != "opening"
+	case "set_cover_position":
+		current, ok := state.Attributes["current_position"].(float64)
+		return !ok || float64(serviceData["position"].(int)) > current
+	default:
+		return false
+	}
+}
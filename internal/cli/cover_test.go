@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func coverState(features int, attributes map[string]interface{}) client.EntityState {
+	state := client.EntityState{
+		EntityID:   "cover.living_blinds",
+		State:      "open",
+		Attributes: map[string]interface{}{"supported_features": float64(features)},
+	}
+	for name, value := range attributes {
+		state.Attributes[name] = value
+	}
+	return state
+}
+
+func TestCoverServiceData(t *testing.T) {
+	all := entity.CoverFeatureOpen | entity.CoverFeatureClose | entity.CoverFeatureSetPosition | entity.CoverFeatureStop |
+		entity.CoverFeatureOpenTilt | entity.CoverFeatureCloseTilt | entity.CoverFeatureStopTilt | entity.CoverFeatureSetTiltPosition
+	blinds := coverState(all, map[string]interface{}{"current_position": float64(40), "current_tilt_position": float64(95)})
+
+	tests := []struct {
+		action      string
+		value       string
+		wantService string
+		want        map[string]interface{}
+	}{
+		{"open", "", "open_cover", map[string]interface{}{}},
+		{"off", "", "close_cover", map[string]interface{}{}},
+		{"stop", "", "stop_cover", map[string]interface{}{}},
+		{"toggle", "", "toggle", map[string]interface{}{}},
+		{"50", "", "set_cover_position", map[string]interface{}{"position": 50}},
+		{"+20", "", "set_cover_position", map[string]interface{}{"position": 60}},
+		{"position", "-50%", "set_cover_position", map[string]interface{}{"position": 0}},
+		{"tilt", "+10", "set_cover_tilt_position", map[string]interface{}{"tilt_position": 100}},
+		{"tilt", "close", "close_cover_tilt", map[string]interface{}{}},
+		{"stop_tilt", "", "stop_cover_tilt", map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" "+tt.value, func(t *testing.T) {
+			service, got, err := coverServiceData(blinds, tt.action, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service != tt.wantService {
+				t.Errorf("expected cover.%s, got cover.%s", tt.wantService, service)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCoverServiceDataChecksCapabilities(t *testing.T) {
+	openClose := coverState(entity.CoverFeatureOpen|entity.CoverFeatureClose, nil)
+
+	for _, args := range [][2]string{{"stop", ""}, {"50", ""}, {"position", "+10"}, {"tilt", "45"}, {"tilt", "open"}} {
+		if _, _, err := coverServiceData(openClose, args[0], args[1]); !errors.Is(err, entity.ErrNotSupported) {
+			t.Errorf("%s %s: expected ErrNotSupported, got %v", args[0], args[1], err)
+		}
+	}
+
+	positioned := coverState(entity.CoverFeatureSetPosition, nil)
+	if _, _, err := coverServiceData(positioned, "+10", ""); err == nil || !strings.Contains(err.Error(), "use an absolute value") {
+		t.Errorf("expected relative moves to need the current position, got %v", err)
+	}
+}
+
+func TestOpensCover(t *testing.T) {
+	closed := coverState(0, map[string]interface{}{"current_position": float64(0)})
+	closed.State = "closed"
+
+	tests := []struct {
+		service string
+		data    map[string]interface{}
+		want    bool
+	}{
+		{"open_cover", nil, true},
+		{"close_cover", nil, false},
+		{"toggle", nil, true},
+		{"set_cover_position", map[string]interface{}{"position": 30}, true},
+		{"set_cover_position", map[string]interface{}{"position": 0}, false},
+	}
+
+	for _, tt := range tests {
+		if got := opensCover(closed, tt.service, tt.data); got != tt.want {
+			t.Errorf("opensCover(%s, %v) = %v, expected %v", tt.service, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestGarageDoorNeedsConfirmation(t *testing.T) {
+	commander, fake := newRecordingCommander(t, client.EntityState{
+		EntityID: "cover.garage_door",
+		State:    "closed",
+		Attributes: map[string]interface{}{
+			"friendly_name":      "Garage Door",
+			"device_class":       "garage",
+			"supported_features": entity.CoverFeatureOpen | entity.CoverFeatureClose,
+		},
+	})
+
+	err := commander.Execute([]string{"garage", "door", "open"})
+	if err == nil || !strings.Contains(err.Error(), "pass --yes") {
+		t.Fatalf("expected opening without a terminal to need --yes, got %v", err)
+	}
+	if code := ExitCode(err); code != ExitUsage {
+		t.Errorf("expected exit code %d, got %d", ExitUsage, code)
+	}
+
+	if err := commander.Execute([]string{"garage", "door", "open", "--yes"}); err != nil {
+		t.Fatalf("expected --yes to skip confirmation, got %v", err)
+	}
+	if call := fake.lastCall(t); call.Domain != "cover" || call.Service != "open_cover" {
+		t.Errorf("expected cover.open_cover, got %s.%s", call.Domain, call.Service)
+	}
+}
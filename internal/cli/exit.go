@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/config"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+// Process exit codes returned by ExitCode. Scripts can rely on these values.
+const (
+	ExitSuccess  = 0
+	ExitFailure  = 1
+	ExitUsage    = 2
+	ExitNotFound = 3
+	ExitTimeout  = 4
+	ExitAuth     = 5
+	ExitOffline  = 6
+	ExitThrottle = 7
+	ExitConfig   = 8
+)
+
+// ExitError attaches a process exit code to an error.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// ExitCode maps an error returned by Commander.Execute to a process exit code.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	var validationErr *config.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return ExitConfig
+	case errors.Is(err, client.ErrUnauthorized):
+		return ExitAuth
+	case errors.Is(err, client.ErrRateLimited):
+		return ExitThrottle
+	case errors.Is(err, client.ErrUnavailable):
+		return ExitOffline
+	case client.IsTimeout(err):
+		return ExitTimeout
+	case errors.Is(err, client.ErrNotFound), errors.Is(err, entity.ErrEntityNotFound):
+		return ExitNotFound
+	case errors.Is(err, entity.ErrNotSupported):
+		return ExitUsage
+	default:
+		return ExitFailure
+	}
+}
+
+// Explain appends a suggested fix to errors whose cause is known.
+func Explain(err error) error {
+	var hint string
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, client.ErrUnauthorized):
+		hint = "check your access token with 'hass config test', or create a new one with 'hass config init'"
+	case errors.Is(err, client.ErrRateLimited):
+		hint = "Home Assistant is throttling requests; wait a moment and try again"
+	case errors.Is(err, client.ErrUnavailable):
+		hint = "check that Home Assistant is running and that the URL in 'hass config show' is reachable"
+	case client.IsTimeout(err):
+		hint = "Home Assistant did not answer in time; raise homeassistant.timeout in the config if it is slow"
+	case errors.Is(err, entity.ErrEntityNotFound):
+		hint = "run 'hass debug' to list entities, or 'hass debug match <area> <type>' to see match scores"
+	case errors.As(err, new(*config.ValidationError)):
+		hint = "fix the lines above, then check again with 'hass config validate'"
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%w\nHint: %s", err, hint)
+}
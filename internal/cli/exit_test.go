@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitSuccess},
+		{"plain", errors.New("boom"), ExitFailure},
+		{"explicit", withExitCode(ExitUsage, errors.New("usage")), ExitUsage},
+		{"unauthorized", fmt.Errorf("failed: %w", &client.APIError{StatusCode: 401}), ExitAuth},
+		{"rate limited", &client.APIError{StatusCode: 429}, ExitThrottle},
+		{"unavailable", fmt.Errorf("request failed: %w", client.ErrUnavailable), ExitOffline},
+		{"timeout", fmt.Errorf("request timed out: %w", context.DeadlineExceeded), ExitTimeout},
+		{"entity not found", fmt.Errorf("failed to find entity: %w", entity.ErrEntityNotFound), ExitNotFound},
+		{"not supported", fmt.Errorf("failed to execute command: %w", &entity.NotSupportedError{}), ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("expected exit code %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	err := Explain(fmt.Errorf("failed to get states: %w", &client.APIError{StatusCode: 401}))
+	if !strings.Contains(err.Error(), "Hint:") || ExitCode(err) != ExitAuth {
+		t.Errorf("expected hint that keeps the exit code, got %v", err)
+	}
+
+	plain := errors.New("boom")
+	if Explain(plain) != plain {
+		t.Error("expected errors without a known cause to be returned as is")
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func (c *Commander) handleFanWithValue(ctx context.Context, caps entity.Capabilities, action, value string) error {
+	service, serviceData, err := fanServiceData(caps, strings.ToLower(action), value)
+	if err != nil {
+		return err
+	}
+
+	target := map[string]interface{}{
+		"entity_id": caps.EntityID,
+	}
+
+	_, err = c.client.CallService(ctx, "fan", service, target, serviceData)
+	return err
+}
+
+// fanServiceData maps an action and optional value to a fan service call:
+//
+//	speed 3, speed 75%      level (see fanPercentage) or percentage
+//	percentage 75           percentage
+//	faster, slower          one speed step up or down
+//	oscillate [on|off]      oscillation
+//	direction forward       direction (forward or reverse)
+//	preset <mode>, <mode>   preset mode
+func fanServiceData(caps entity.Capabilities, action, value string) (string, map[string]interface{}, error) {
+	switch action {
+	case "speed", "percentage":
+		if !caps.Has(entity.FanFeatureSetSpeed) {
+			return "", nil, caps.Unsupported(action, "it has no speed control")
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		percentage, err := fanPercentage(caps, value, action == "speed")
+		if err != nil {
+			return "", nil, err
+		}
+		return "set_percentage", map[string]interface{}{"percentage": percentage}, nil
+
+	case "faster", "slower":
+		if !caps.Has(entity.FanFeatureSetSpeed) {
+			return "", nil, caps.Unsupported(action, "it has no speed control")
+		}
+		service := "increase_speed"
+		if action == "slower" {
+			service = "decrease_speed"
+		}
+		return service, map[string]interface{}{}, nil
+
+	case "oscillate", "oscillation":
+		if !caps.Has(entity.FanFeatureOscillate) {
+			return "", nil, caps.Unsupported("oscillate", "it cannot oscillate")
+		}
+		oscillating := true
+		if value != "" {
+			var err error
+			if oscillating, err = parseOnOff(value); err != nil {
+				return "", nil, fmt.Errorf("invalid oscillate value: %s (use on or off)", value)
+			}
+		}
+		return "oscillate", map[string]interface{}{"oscillating": oscillating}, nil
+
+	case "direction":
+		if !caps.Has(entity.FanFeatureDirection) {
+			return "", nil, caps.Unsupported("direction", "it cannot change direction")
+		}
+		direction := strings.ToLower(value)
+		if direction != "forward" && direction != "reverse" {
+			return "", nil, fmt.Errorf("invalid direction: %s (use forward or reverse)", value)
+		}
+		return "set_direction", map[string]interface{}{"direction": direction}, nil
+
+	case "preset", "preset_mode", "mode":
+		if !caps.Has(entity.FanFeaturePresetMode) {
+			return "", nil, caps.Unsupported("preset", "it has no preset modes")
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		preset, err := caps.MatchOption("preset", value, caps.PresetModes)
+		if err != nil {
+			return "", nil, err
+		}
+		return "set_preset_mode", map[string]interface{}{"preset_mode": preset}, nil
+	}
+
+	// A preset can be named directly, as in "hass bedroom fan sleep".
+	if value == "" && caps.Has(entity.FanFeaturePresetMode) && len(caps.PresetModes) > 0 {
+		if preset, err := caps.MatchOption("preset", action, caps.PresetModes); err == nil {
+			return "set_preset_mode", map[string]interface{}{"preset_mode": preset}, nil
+		}
+	}
+
+	return "", nil, caps.Unsupported(action, "")
+}
+
+// fanPercentage converts a speed to a percentage. A value with a % suffix is
+// always a percentage. Otherwise, when levels is set and the fan has a
+// percentage_step, whole numbers up to the number of speeds are levels, so
+// "speed 3" on a three-speed fan is 100%.
+func fanPercentage(caps entity.Capabilities, value string, levels bool) (int, error) {
+	raw := strings.TrimSpace(value)
+	percent := strings.HasSuffix(raw, "%")
+
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid speed value: %s", value)
+	}
+
+	if levels && !percent && caps.PercentageStep > 0 && speed == math.Trunc(speed) {
+		count := math.Round(100 / caps.PercentageStep)
+		if speed >= 0 && speed <= count {
+			return int(math.Round(speed * 100 / count)), nil
+		}
+	}
+
+	if speed < 0 || speed > 100 {
+		return 0, fmt.Errorf("speed must be between 0 and 100")
+	}
+	return int(math.Round(speed)), nil
+}
+
+func parseOnOff(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes", "1":
+		return true, nil
+	case "off", "false", "no", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("not on or off: %s", value)
+	}
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func fanCapabilities(features int, step float64) entity.Capabilities {
+	return entity.CapabilitiesOf(client.EntityState{
+		EntityID: "fan.bedroom",
+		Attributes: map[string]interface{}{
+			"supported_features": float64(features),
+			"percentage_step":    step,
+			"preset_modes":       []interface{}{"auto", "sleep", "Nature Breeze"},
+		},
+	})
+}
+
+func TestFanServiceData(t *testing.T) {
+	all := entity.FanFeatureSetSpeed | entity.FanFeatureOscillate | entity.FanFeatureDirection | entity.FanFeaturePresetMode
+	threeSpeed := fanCapabilities(all, 100.0/3)
+
+	tests := []struct {
+		action      string
+		value       string
+		wantService string
+		want        map[string]interface{}
+	}{
+		{"speed", "1", "set_percentage", map[string]interface{}{"percentage": 33}},
+		{"speed", "3", "set_percentage", map[string]interface{}{"percentage": 100}},
+		{"speed", "0", "set_percentage", map[string]interface{}{"percentage": 0}},
+		{"speed", "3%", "set_percentage", map[string]interface{}{"percentage": 3}},
+		{"speed", "50", "set_percentage", map[string]interface{}{"percentage": 50}},
+		{"percentage", "2", "set_percentage", map[string]interface{}{"percentage": 2}},
+		{"faster", "", "increase_speed", map[string]interface{}{}},
+		{"slower", "", "decrease_speed", map[string]interface{}{}},
+		{"oscillate", "", "oscillate", map[string]interface{}{"oscillating": true}},
+		{"oscillate", "off", "oscillate", map[string]interface{}{"oscillating": false}},
+		{"direction", "Reverse", "set_direction", map[string]interface{}{"direction": "reverse"}},
+		{"preset", "nature breeze", "set_preset_mode", map[string]interface{}{"preset_mode": "Nature Breeze"}},
+		{"sleep", "", "set_preset_mode", map[string]interface{}{"preset_mode": "sleep"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" "+tt.value, func(t *testing.T) {
+			service, got, err := fanServiceData(threeSpeed, tt.action, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service != tt.wantService {
+				t.Errorf("expected fan.%s, got fan.%s", tt.wantService, service)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFanServiceDataChecksCapabilities(t *testing.T) {
+	speedOnly := fanCapabilities(entity.FanFeatureSetSpeed, 1)
+
+	tests := []struct {
+		name   string
+		caps   entity.Capabilities
+		action string
+		value  string
+	}{
+		{"speed without support", fanCapabilities(0, 0), "speed", "2"},
+		{"oscillate without support", speedOnly, "oscillate", "on"},
+		{"direction without support", speedOnly, "direction", "reverse"},
+		{"preset without support", speedOnly, "preset", "sleep"},
+		{"unknown preset", fanCapabilities(entity.FanFeaturePresetMode, 0), "preset", "turbo"},
+		{"unknown action", speedOnly, "sleep", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := fanServiceData(tt.caps, tt.action, tt.value)
+			if !errors.Is(err, entity.ErrNotSupported) {
+				t.Errorf("expected ErrNotSupported, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFanPercentageWithoutStep(t *testing.T) {
+	caps := fanCapabilities(entity.FanFeatureSetSpeed, 0)
+
+	if got, err := fanPercentage(caps, "3", true); err != nil || got != 3 {
+		t.Errorf("expected 3%% when percentage_step is unknown, got %d (err=%v)", got, err)
+	}
+	if _, err := fanPercentage(caps, "150", true); err == nil {
+		t.Error("expected an error for a speed above 100")
+	}
+}
+
+func TestFanRouting(t *testing.T) {
+	fan := client.EntityState{
+		EntityID: "fan.bedroom",
+		State:    "on",
+		Attributes: map[string]interface{}{
+			"friendly_name":      "Bedroom Fan",
+			"supported_features": float64(entity.FanFeatureSetSpeed | entity.FanFeatureOscillate | entity.FanFeaturePresetMode),
+			"percentage_step":    100.0 / 3,
+			"preset_modes":       []interface{}{"auto", "sleep"},
+		},
+	}
+
+	testRouting(t, []client.EntityState{fan}, []routingCase{
+		{[]string{"bedroom", "fan", "speed", "2"}, "fan", "set_percentage",
+			map[string]interface{}{"entity_id": "fan.bedroom", "percentage": 67.0}},
+		{[]string{"bedroom", "fan", "oscillate", "off"}, "fan", "oscillate",
+			map[string]interface{}{"entity_id": "fan.bedroom", "oscillating": false}},
+		{[]string{"bedroom", "fan", "sleep"}, "fan", "set_preset_mode",
+			map[string]interface{}{"entity_id": "fan.bedroom", "preset_mode": "sleep"}},
+		{[]string{"bedroom", "fan", "faster"}, "fan", "increase_speed",
+			map[string]interface{}{"entity_id": "fan.bedroom"}},
+	})
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+type flagValues map[string][]string
+
+// splitFlags separates "--name value" and "--name=value" options from
+// positional arguments. Flags listed in valueFlags consume the following
+// argument; any other flag is treated as a boolean. A bare "--" ends flag
+// parsing. Single-dash arguments such as "-5" stay positional so relative
+// values keep working.
+func splitFlags(args []string, valueFlags ...string) ([]string, flagValues, error) {
+	takesValue := make(map[string]bool, len(valueFlags))
+	for _, name := range valueFlags {
+		takesValue[name] = true
+	}
+
+	var positional []string
+	flags := make(flagValues)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name := strings.TrimPrefix(arg, "--")
+		value, hasValue := "", false
+		if idx := strings.Index(name, "="); idx >= 0 {
+			name, value, hasValue = name[:idx], name[idx+1:], true
+		}
+
+		if !takesValue[name] {
+			if !hasValue {
+				value = "true"
+			}
+			flags[name] = append(flags[name], value)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("flag --%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		flags[name] = append(flags[name], value)
+	}
+
+	return positional, flags, nil
+}
+
+func (f flagValues) get(name string) (string, bool) {
+	values := f[name]
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+func (f flagValues) has(name string) bool {
+	value, ok := f.get(name)
+	return ok && value != "false"
+}
@@ -0,0 +1,361 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func (c *Commander) handleHelperWithValue(ctx context.Context, caps entity.Capabilities, action, value string) error {
+	service, serviceData, err := helperServiceData(caps, action, value)
+	if err != nil {
+		return err
+	}
+
+	target := map[string]interface{}{
+		"entity_id": caps.EntityID,
+	}
+
+	_, err = c.client.CallService(ctx, caps.Domain, service, target, serviceData)
+	return err
+}
+
+// helperServiceData maps an action and optional value to a service call on
+// an input_number, input_select, input_text, input_datetime, counter or
+// timer helper, or on the button, number, select and text entities that
+// integrations expose, which validate their values the same way.
+// input_boolean needs nothing beyond on, off and toggle.
+func helperServiceData(caps entity.Capabilities, action, value string) (string, map[string]interface{}, error) {
+	keyword := strings.ToLower(action)
+
+	switch caps.Domain {
+	case "button", "input_button":
+		if keyword != "press" && keyword != "push" {
+			return "", nil, caps.Unsupported(action, "")
+		}
+		return "press", map[string]interface{}{}, nil
+	case "input_number", "number":
+		return numberServiceData(caps, keyword, action, value)
+	case "input_select", "select":
+		return selectServiceData(caps, keyword, action, value)
+	case "input_text", "text":
+		return textServiceData(caps, keyword, action, value)
+	case "input_datetime":
+		return datetimeServiceData(caps, keyword, action, value)
+	case "counter":
+		return counterServiceData(caps, keyword, value)
+	case "timer":
+		return timerServiceData(caps, keyword, value)
+	}
+	return "", nil, caps.Unsupported(action, "")
+}
+
+// setKeywords introduce the value to set, as in "set 42" or "to 42".
+var setKeywords = map[string]bool{"set": true, "to": true, "value": true, "set_value": true}
+
+// joinValue returns the value an action sets: the value after a set keyword,
+// or the action and value together, as in "hass house select movie night".
+func joinValue(keyword, action, value string) string {
+	if setKeywords[keyword] {
+		return value
+	}
+	return strings.TrimSpace(action + " " + value)
+}
+
+// numberServiceData handles input_number helpers and number entities:
+//
+//	set 42, 42                   value within min, max and step
+//	increment, up, decrement     one step up or down (input_number only)
+func numberServiceData(caps entity.Capabilities, keyword, action, value string) (string, map[string]interface{}, error) {
+	switch keyword {
+	case "increment", "up", "decrement", "down":
+		if caps.Domain != "input_number" {
+			return "", nil, caps.Unsupported(action, "give the value to set, as in set 42")
+		}
+		if keyword == "up" || keyword == "increment" {
+			return "increment", map[string]interface{}{}, nil
+		}
+		return "decrement", map[string]interface{}{}, nil
+	}
+
+	raw := joinValue(keyword, action, value)
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		if !setKeywords[keyword] {
+			return "", nil, caps.Unsupported(action, "")
+		}
+		return "", nil, fmt.Errorf("invalid number: %s", raw)
+	}
+	if err := checkNumber(caps, number); err != nil {
+		return "", nil, err
+	}
+	return "set_value", map[string]interface{}{"value": number}, nil
+}
+
+// checkNumber verifies number is within the entity's min and max and falls
+// on a step from min, suggesting the nearest values that do.
+func checkNumber(caps entity.Capabilities, number float64) error {
+	if err := caps.CheckRange("value", number, caps.Min, caps.Max); err != nil {
+		return err
+	}
+	if caps.Step <= 0 {
+		return nil
+	}
+
+	steps := (number - caps.Min) / caps.Step
+	if math.Abs(steps-math.Round(steps)) < 1e-9 {
+		return nil
+	}
+
+	round := func(x float64) float64 { return math.Round(x*1e6) / 1e6 }
+	below := round(caps.Min + math.Floor(steps)*caps.Step)
+	above := round(below + caps.Step)
+	suggestion := fmt.Sprintf("%g or %g", below, above)
+	if caps.Max != 0 && above > caps.Max {
+		suggestion = fmt.Sprintf("%g", below)
+	}
+	return &entity.NotSupportedError{
+		EntityID: caps.EntityID,
+		Action:   fmt.Sprintf("value %g", number),
+		Reason:   fmt.Sprintf("values go in steps of %g from %g; try %s", caps.Step, caps.Min, suggestion),
+	}
+}
+
+// selectServiceData handles input_select helpers and select entities:
+//
+//	select movie night, movie night   option, matched fuzzily
+//	next, previous, first, last       move through the options
+func selectServiceData(caps entity.Capabilities, keyword, action, value string) (string, map[string]interface{}, error) {
+	if value == "" {
+		switch keyword {
+		case "next", "previous", "first", "last":
+			return "select_" + keyword, map[string]interface{}{}, nil
+		case "prev":
+			return "select_previous", map[string]interface{}{}, nil
+		}
+	}
+
+	if keyword == "select" || keyword == "option" {
+		keyword = "set"
+	}
+	raw := joinValue(keyword, action, value)
+	if raw == "" {
+		return "", nil, fmt.Errorf("%s needs an option", action)
+	}
+
+	option, err := caps.MatchOptionFuzzy("option", raw, caps.Options)
+	if err != nil {
+		return "", nil, err
+	}
+	return "select_option", map[string]interface{}{"option": option}, nil
+}
+
+// textServiceData handles input_text helpers and text entities:
+//
+//	set <text>       text within the length limits and pattern
+//	clear            empty text
+func textServiceData(caps entity.Capabilities, keyword, action, value string) (string, map[string]interface{}, error) {
+	var text string
+	switch {
+	case keyword == "clear" && value == "":
+	case setKeywords[keyword] && value != "":
+		text = value
+	case setKeywords[keyword]:
+		return "", nil, fmt.Errorf("%s needs text; use clear to empty it", action)
+	default:
+		return "", nil, caps.Unsupported(action, "use set <text> or clear")
+	}
+
+	if err := checkText(caps, text); err != nil {
+		return "", nil, err
+	}
+	return "set_value", map[string]interface{}{"value": text}, nil
+}
+
+// checkText verifies text against the entity's min and max length and its
+// pattern.
+func checkText(caps entity.Capabilities, text string) error {
+	length := float64(utf8.RuneCountInString(text))
+	if length < caps.Min || (caps.Max > 0 && length > caps.Max) {
+		// A max of 0 means the entity sets no upper bound.
+		reason := fmt.Sprintf("must be %g to %g characters long", caps.Min, caps.Max)
+		if caps.Max == 0 {
+			reason = fmt.Sprintf("must be at least %g characters long", caps.Min)
+		}
+		return &entity.NotSupportedError{
+			EntityID: caps.EntityID,
+			Action:   fmt.Sprintf("text %q", text),
+			Reason:   reason,
+		}
+	}
+
+	if caps.Pattern != "" {
+		pattern, err := regexp.Compile("^(?:" + caps.Pattern + ")$")
+		if err == nil && !pattern.MatchString(text) {
+			return &entity.NotSupportedError{
+				EntityID: caps.EntityID,
+				Action:   fmt.Sprintf("text %q", text),
+				Reason:   fmt.Sprintf("must match the pattern %s", caps.Pattern),
+			}
+		}
+	}
+	return nil
+}
+
+// datetimeLayouts are the date and time forms accepted for input_datetime,
+// with whether each gives a date and a time.
+var datetimeLayouts = []struct {
+	layout           string
+	hasDate, hasTime bool
+}{
+	{"2006-01-02 15:04:05", true, true},
+	{"2006-01-02 15:04", true, true},
+	{"2006-01-02T15:04:05", true, true},
+	{"2006-01-02T15:04", true, true},
+	{"2006-01-02", true, false},
+	{"15:04:05", false, true},
+	{"15:04", false, true},
+}
+
+// datetimeServiceData handles input_datetime helpers:
+//
+//	set 2026-10-18 07:30, 07:30, 2026-10-18, now
+//
+// Only the parts the helper has (has_date, has_time) may be given.
+func datetimeServiceData(caps entity.Capabilities, keyword, action, value string) (string, map[string]interface{}, error) {
+	raw := joinValue(keyword, action, value)
+	if raw == "" {
+		return "", nil, fmt.Errorf("%s needs a date or time", action)
+	}
+
+	// Helpers that report neither attribute accept either part.
+	known := caps.HasDate || caps.HasTime
+
+	var at time.Time
+	var hasDate, hasTime bool
+	if strings.EqualFold(raw, "now") {
+		at, hasDate, hasTime = time.Now(), caps.HasDate || !known, caps.HasTime || !known
+	} else {
+		parsed := false
+		for _, form := range datetimeLayouts {
+			if t, err := time.Parse(form.layout, raw); err == nil {
+				at, hasDate, hasTime, parsed = t, form.hasDate, form.hasTime, true
+				break
+			}
+		}
+		if !parsed {
+			return "", nil, fmt.Errorf("invalid date or time: %s (use 2006-01-02, 15:04 or both)", raw)
+		}
+	}
+
+	if known && hasDate && !caps.HasDate {
+		return "", nil, caps.Unsupported("date", "it only holds a time, as in 07:30")
+	}
+	if known && hasTime && !caps.HasTime {
+		return "", nil, caps.Unsupported("time", "it only holds a date, as in 2026-10-18")
+	}
+
+	switch {
+	case hasDate && hasTime:
+		return "set_datetime", map[string]interface{}{"datetime": at.Format("2006-01-02 15:04:05")}, nil
+	case hasDate:
+		return "set_datetime", map[string]interface{}{"date": at.Format("2006-01-02")}, nil
+	default:
+		return "set_datetime", map[string]interface{}{"time": at.Format("15:04:05")}, nil
+	}
+}
+
+// counterServiceData handles counters:
+//
+//	increment, up, decrement, down, reset
+//	set 5                        value within minimum and maximum
+func counterServiceData(caps entity.Capabilities, keyword, value string) (string, map[string]interface{}, error) {
+	switch keyword {
+	case "increment", "up":
+		return "increment", map[string]interface{}{}, nil
+	case "decrement", "down":
+		return "decrement", map[string]interface{}{}, nil
+	case "reset":
+		return "reset", map[string]interface{}{}, nil
+	}
+
+	if !setKeywords[keyword] {
+		return "", nil, caps.Unsupported(keyword, "")
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid counter value: %s (use a whole number)", value)
+	}
+	if err := caps.CheckRange("value", float64(count), caps.Min, caps.Max); err != nil {
+		return "", nil, err
+	}
+	return "set_value", map[string]interface{}{"value": count}, nil
+}
+
+// timerServiceData handles timers:
+//
+//	start [duration]             start or resume, optionally for 90, 10m or 1:30:00
+//	pause, cancel, stop, finish
+func timerServiceData(caps entity.Capabilities, keyword, value string) (string, map[string]interface{}, error) {
+	switch keyword {
+	case "start", "resume":
+		if value == "" {
+			return "start", map[string]interface{}{}, nil
+		}
+		duration, err := parseTimerDuration(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return "start", map[string]interface{}{"duration": formatTimerDuration(duration)}, nil
+	case "pause":
+		return "pause", map[string]interface{}{}, nil
+	case "cancel", "stop":
+		return "cancel", map[string]interface{}{}, nil
+	case "finish":
+		return "finish", map[string]interface{}{}, nil
+	}
+	return "", nil, caps.Unsupported(keyword, "")
+}
+
+// parseTimerDuration reads seconds ("90"), a duration ("10m") or
+// "H:MM:SS" / "M:SS".
+func parseTimerDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return duration, nil
+	}
+
+	if parts := strings.Split(value, ":"); len(parts) == 2 || len(parts) == 3 {
+		var total time.Duration
+		valid := true
+		for _, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				valid = false
+				break
+			}
+			total = total*60 + time.Duration(n)
+		}
+		if valid && total > 0 {
+			return total * time.Second, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid duration %q (use seconds like 90, a duration like 10m, or 1:30:00)", value)
+}
+
+func formatTimerDuration(d time.Duration) string {
+	total := int(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+}
@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func helperCapabilities(entityID string, attributes map[string]interface{}) entity.Capabilities {
+	return entity.CapabilitiesOf(client.EntityState{EntityID: entityID, Attributes: attributes})
+}
+
+func TestHelperServiceData(t *testing.T) {
+	number := helperCapabilities("input_number.pool_temp", map[string]interface{}{"min": float64(10), "max": float64(40), "step": 0.5})
+	mode := helperCapabilities("input_select.house_mode", map[string]interface{}{"options": []interface{}{"Home", "Away", "Movie Night"}})
+	text := helperCapabilities("input_text.note", map[string]interface{}{"min": float64(0), "max": float64(20)})
+	alarm := helperCapabilities("input_datetime.wake_up", map[string]interface{}{"has_date": false, "has_time": true})
+	trip := helperCapabilities("input_datetime.trip", map[string]interface{}{"has_date": true, "has_time": true})
+	counter := helperCapabilities("counter.coffees", map[string]interface{}{"minimum": float64(0), "maximum": float64(10)})
+	timer := helperCapabilities("timer.laundry", nil)
+
+	tests := []struct {
+		caps        entity.Capabilities
+		action      string
+		value       string
+		wantService string
+		want        map[string]interface{}
+	}{
+		{number, "set", "25.5", "set_value", map[string]interface{}{"value": 25.5}},
+		{number, "30", "", "set_value", map[string]interface{}{"value": 30.0}},
+		{number, "up", "", "increment", map[string]interface{}{}},
+		{mode, "movie", "night", "select_option", map[string]interface{}{"option": "Movie Night"}},
+		{mode, "select", "away", "select_option", map[string]interface{}{"option": "Away"}},
+		{mode, "next", "", "select_next", map[string]interface{}{}},
+		{text, "set", "Feed the cat", "set_value", map[string]interface{}{"value": "Feed the cat"}},
+		{text, "clear", "", "set_value", map[string]interface{}{"value": ""}},
+		{alarm, "set", "07:30", "set_datetime", map[string]interface{}{"time": "07:30:00"}},
+		{trip, "2026-12-24", "18:00", "set_datetime", map[string]interface{}{"datetime": "2026-12-24 18:00:00"}},
+		{trip, "set", "2026-12-24", "set_datetime", map[string]interface{}{"date": "2026-12-24"}},
+		{counter, "increment", "", "increment", map[string]interface{}{}},
+		{counter, "reset", "", "reset", map[string]interface{}{}},
+		{counter, "set", "4", "set_value", map[string]interface{}{"value": 4}},
+		{timer, "start", "", "start", map[string]interface{}{}},
+		{timer, "start", "10m", "start", map[string]interface{}{"duration": "0:10:00"}},
+		{timer, "start", "90", "start", map[string]interface{}{"duration": "0:01:30"}},
+		{timer, "start", "1:30:00", "start", map[string]interface{}{"duration": "1:30:00"}},
+		{timer, "cancel", "", "cancel", map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.caps.EntityID+" "+tt.action+" "+tt.value, func(t *testing.T) {
+			service, got, err := helperServiceData(tt.caps, tt.action, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service != tt.wantService {
+				t.Errorf("expected %s.%s, got %s.%s", tt.caps.Domain, tt.wantService, tt.caps.Domain, service)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHelperServiceDataValidation(t *testing.T) {
+	number := helperCapabilities("input_number.pool_temp", map[string]interface{}{"min": float64(10), "max": float64(40), "step": 0.5})
+	mode := helperCapabilities("input_select.house_mode", map[string]interface{}{"options": []interface{}{"Home", "Away"}})
+	code := helperCapabilities("input_text.code", map[string]interface{}{"min": float64(4), "max": float64(4), "pattern": `\d+`})
+	password := helperCapabilities("text.wifi_password", map[string]interface{}{"min": float64(8)})
+	alarm := helperCapabilities("input_datetime.wake_up", map[string]interface{}{"has_date": false, "has_time": true})
+	counter := helperCapabilities("counter.coffees", map[string]interface{}{"minimum": float64(0), "maximum": float64(10)})
+	timer := helperCapabilities("timer.laundry", nil)
+
+	tests := []struct {
+		caps          entity.Capabilities
+		action, value string
+		message       string
+	}{
+		{number, "set", "45", "between 10 and 40"},
+		{number, "set", "25.3", "try 25 or 25.5"},
+		{number, "set", "warm", "invalid number"},
+		{mode, "vacation", "", "supported: Home, Away"},
+		{code, "set", "12a4", "must match the pattern"},
+		{code, "set", "123", "4 to 4 characters"},
+		{password, "set", "secret", "at least 8 characters long"},
+		{alarm, "set", "2026-10-18", "only holds a time"},
+		{counter, "set", "11", "between 0 and 10"},
+		{timer, "start", "soon", "invalid duration"},
+	}
+
+	for _, tt := range tests {
+		_, _, err := helperServiceData(tt.caps, tt.action, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s %s %s: expected an error containing %q, got %v", tt.caps.EntityID, tt.action, tt.value, tt.message, err)
+		}
+	}
+
+	if _, _, err := helperServiceData(number, "max", ""); !errors.Is(err, entity.ErrNotSupported) {
+		t.Errorf("expected an unknown action to be unsupported, got %v", err)
+	}
+}
+
+func TestEntityValueServiceData(t *testing.T) {
+	restart := helperCapabilities("button.router_restart", nil)
+	volume := helperCapabilities("number.speaker_volume", map[string]interface{}{"min": float64(0), "max": float64(100), "step": float64(5)})
+	preset := helperCapabilities("select.washer_program", map[string]interface{}{"options": []interface{}{"Cotton", "Synthetics", "Quick Wash"}})
+	name := helperCapabilities("text.printer_name", map[string]interface{}{"min": float64(1), "max": float64(12), "pattern": "[A-Za-z ]+"})
+
+	tests := []struct {
+		caps        entity.Capabilities
+		action      string
+		value       string
+		wantService string
+		want        map[string]interface{}
+	}{
+		{restart, "press", "", "press", map[string]interface{}{}},
+		{volume, "set", "35", "set_value", map[string]interface{}{"value": 35.0}},
+		{preset, "quick", "", "select_option", map[string]interface{}{"option": "Quick Wash"}},
+		{preset, "previous", "", "select_previous", map[string]interface{}{}},
+		{name, "set", "Office", "set_value", map[string]interface{}{"value": "Office"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.caps.EntityID+" "+tt.action+" "+tt.value, func(t *testing.T) {
+			service, got, err := helperServiceData(tt.caps, tt.action, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service != tt.wantService {
+				t.Errorf("expected %s.%s, got %s.%s", tt.caps.Domain, tt.wantService, tt.caps.Domain, service)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	invalid := []struct {
+		caps          entity.Capabilities
+		action, value string
+		message       string
+	}{
+		{restart, "on", "", "supported: press"},
+		{volume, "set", "37", "try 35 or 40"},
+		{volume, "set", "120", "between 0 and 100"},
+		{volume, "up", "", "as in set 42"},
+		{preset, "wool", "", "supported: Cotton, Synthetics, Quick Wash"},
+		{name, "set", "Office 2", "must match the pattern"},
+	}
+
+	for _, tt := range invalid {
+		_, _, err := helperServiceData(tt.caps, tt.action, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s %s %s: expected an error containing %q, got %v", tt.caps.EntityID, tt.action, tt.value, tt.message, err)
+		}
+	}
+}
+
+func TestHelperRouting(t *testing.T) {
+	helper := func(entityID, name string, attributes map[string]interface{}) client.EntityState {
+		if attributes == nil {
+			attributes = map[string]interface{}{}
+		}
+		attributes["friendly_name"] = name
+		return client.EntityState{EntityID: entityID, State: "idle", Attributes: attributes}
+	}
+	states := []client.EntityState{
+		helper("input_boolean.guest_mode", "Guest Mode", nil),
+		helper("input_number.pool_temp", "Pool Temperature", map[string]interface{}{"min": float64(10), "max": float64(40), "step": 0.5}),
+		helper("input_select.house_mode", "House Mode", map[string]interface{}{"options": []interface{}{"Home", "Away", "Movie Night"}}),
+		helper("input_text.kitchen_note", "Kitchen Note", map[string]interface{}{"min": float64(0), "max": float64(20)}),
+		helper("counter.coffees", "Office Coffees", map[string]interface{}{"minimum": float64(0), "maximum": float64(10)}),
+		helper("timer.laundry", "Laundry Timer", nil),
+	}
+
+	testRouting(t, states, []routingCase{
+		{[]string{"guest", "flag", "on"}, "input_boolean", "turn_on",
+			map[string]interface{}{"entity_id": "input_boolean.guest_mode"}},
+		{[]string{"pool", "slider", "set", "25.5"}, "input_number", "set_value",
+			map[string]interface{}{"entity_id": "input_number.pool_temp", "value": 25.5}},
+		{[]string{"house", "dropdown", "movie", "night"}, "input_select", "select_option",
+			map[string]interface{}{"entity_id": "input_select.house_mode", "option": "Movie Night"}},
+		{[]string{"kitchen", "text", "set", "Feed the cat"}, "input_text", "set_value",
+			map[string]interface{}{"entity_id": "input_text.kitchen_note", "value": "Feed the cat"}},
+		{[]string{"office", "counter", "increment"}, "counter", "increment",
+			map[string]interface{}{"entity_id": "counter.coffees"}},
+		{[]string{"laundry", "timer", "start", "10m"}, "timer", "start",
+			map[string]interface{}{"entity_id": "timer.laundry", "duration": "0:10:00"}},
+	})
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func (c *Commander) handleLawnMowerWithValue(ctx context.Context, caps entity.Capabilities, action string) error {
+	service, err := lawnMowerService(caps, strings.ToLower(action))
+	if err != nil {
+		return err
+	}
+
+	target := map[string]interface{}{
+		"entity_id": caps.EntityID,
+	}
+
+	_, err = c.client.CallService(ctx, "lawn_mower", service, target, map[string]interface{}{})
+	return err
+}
+
+// lawnMowerService maps an action to a lawn_mower service:
+//
+//	start, mow, on          start mowing
+//	pause
+//	dock, return, home, off return to the dock
+func lawnMowerService(caps entity.Capabilities, action string) (string, error) {
+	var service string
+	var feature int
+	switch action {
+	case "start", "mow", "on", "start_mowing":
+		service, feature = "start_mowing", entity.LawnMowerFeatureStartMowing
+	case "pause":
+		service, feature = "pause", entity.LawnMowerFeaturePause
+	case "dock", "return", "home", "off":
+		service, feature = "dock", entity.LawnMowerFeatureDock
+	default:
+		return "", caps.Unsupported(action, "")
+	}
+
+	if !caps.Has(feature) {
+		return "", caps.Unsupported(action, "")
+	}
+	return service, nil
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func TestLawnMowerService(t *testing.T) {
+	mower := entity.CapabilitiesOf(client.EntityState{
+		EntityID:   "lawn_mower.automower",
+		Attributes: map[string]interface{}{"supported_features": float64(entity.LawnMowerFeatureStartMowing | entity.LawnMowerFeatureDock)},
+	})
+
+	for action, want := range map[string]string{"start": "start_mowing", "mow": "start_mowing", "dock": "dock", "off": "dock"} {
+		if got, err := lawnMowerService(mower, action); err != nil || got != want {
+			t.Errorf("lawnMowerService(%q) = %q (err=%v), expected %q", action, got, err, want)
+		}
+	}
+
+	if _, err := lawnMowerService(mower, "pause"); !errors.Is(err, entity.ErrNotSupported) {
+		t.Errorf("expected pausing to be unsupported, got %v", err)
+	}
+}
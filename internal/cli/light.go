@@ -0,0 +1,363 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+// brightnessStep is how far dim and bright move a light, in percent.
+const brightnessStep = 25
+
+// colorTempPresets name common white temperatures in kelvin.
+var colorTempPresets = map[string]int{
+	"candle":   2200,
+	"warm":     2700,
+	"soft":     3000,
+	"neutral":  4000,
+	"white":    4000,
+	"cool":     5000,
+	"cold":     5000,
+	"daylight": 6500,
+}
+
+func (c *Commander) handleLightWithValue(ctx context.Context, caps entity.Capabilities, action, value string, transition string) error {
+	serviceData, err := lightServiceData(caps, strings.ToLower(action), value)
+	if err != nil {
+		return err
+	}
+
+	if transition != "" {
+		if serviceData, err = withTransition(caps, serviceData, transition); err != nil {
+			return err
+		}
+	}
+
+	target := map[string]interface{}{
+		"entity_id": caps.EntityID,
+	}
+
+	_, err = c.client.CallService(ctx, "light", "turn_on", target, serviceData)
+	return err
+}
+
+// switchLight turns a light on or off with a transition, which the generic
+// turn_on/turn_off calls cannot carry.
+func (c *Commander) switchLight(ctx context.Context, caps entity.Capabilities, service, transition string) error {
+	serviceData, err := withTransition(caps, map[string]interface{}{}, transition)
+	if err != nil {
+		return err
+	}
+
+	target := map[string]interface{}{
+		"entity_id": caps.EntityID,
+	}
+
+	_, err = c.client.CallService(ctx, "light", service, target, serviceData)
+	return err
+}
+
+// lightServiceData maps an action and optional value to light.turn_on data:
+//
+//	50, 50%                 brightness in percent (0-100)
+//	brightness 128|50%      brightness, raw (0-255) unless given as a percentage
+//	+10, -10%, dim, bright  relative brightness (dim and bright step 25%)
+//	warm, cool, kelvin 3000 color temperature (values below 1000 are mireds)
+//	red, #ff8800, rgb 255,0,0, hs 30,100, xy 0.3,0.3, color <name>
+//	effect <name>, flash [short|long]
+func lightServiceData(caps entity.Capabilities, action, value string) (map[string]interface{}, error) {
+	switch {
+	case action == "brightness":
+		if !caps.SupportsBrightness() {
+			return nil, caps.Unsupported("brightness", "it can only be switched on and off")
+		}
+		if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+			return relativeBrightness(value)
+		}
+		if strings.HasSuffix(value, "%") {
+			return percentBrightness(value)
+		}
+		brightness, err := entity.ParseNumericValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid brightness value: %s", value)
+		}
+		if brightness < 0 || brightness > 255 {
+			return nil, fmt.Errorf("brightness must be between 0 and 255")
+		}
+		return map[string]interface{}{"brightness": int(brightness)}, nil
+
+	case action == "dim" || action == "dimmer" || action == "bright" || action == "brighter":
+		if !caps.SupportsBrightness() {
+			return nil, caps.Unsupported(action, "it can only be switched on and off")
+		}
+		step := strconv.Itoa(brightnessStep)
+		if value != "" {
+			step = strings.TrimSuffix(value, "%")
+		}
+		sign := "+"
+		if strings.HasPrefix(action, "dim") {
+			sign = "-"
+		}
+		return relativeBrightness(sign + step)
+
+	case strings.HasPrefix(action, "+") || strings.HasPrefix(action, "-"):
+		if !caps.SupportsBrightness() {
+			return nil, caps.Unsupported("brightness", "it can only be switched on and off")
+		}
+		return relativeBrightness(action)
+
+	case isNumeric(strings.TrimSuffix(action, "%")):
+		if !caps.SupportsBrightness() {
+			return nil, caps.Unsupported("brightness", "it can only be switched on and off")
+		}
+		if strings.HasSuffix(action, "%") {
+			return percentBrightness(action)
+		}
+		// A bare number is always a percentage; raw values need "brightness".
+		level, _ := entity.ParseNumericValue(action)
+		if level < 0 || level > 100 {
+			return nil, fmt.Errorf("invalid brightness value %q (use 0-100, or brightness 0-255 for raw values)", action)
+		}
+		return map[string]interface{}{"brightness_pct": int(level)}, nil
+
+	case colorTempPresets[action] != 0:
+		return colorTemp(caps, colorTempPresets[action])
+
+	case action == "kelvin" || action == "temp" || action == "ct":
+		if preset, ok := colorTempPresets[strings.ToLower(value)]; ok {
+			return colorTemp(caps, preset)
+		}
+		kelvin, err := parseColorTemp(value)
+		if err != nil {
+			return nil, err
+		}
+		return colorTemp(caps, kelvin)
+
+	case action == "rgb" || strings.HasPrefix(action, "rgb("):
+		if value == "" {
+			value = strings.TrimSuffix(strings.TrimPrefix(action, "rgb("), ")")
+		}
+		rgb, err := parseComponents(value, 3, 0, 255)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RGB color %q: %w", value, err)
+		}
+		return colorData(caps, "rgb_color", rgb)
+
+	case action == "hs":
+		hs, err := parseComponents(value, 2, 0, 360)
+		if err != nil || hs[1] > 100 {
+			return nil, fmt.Errorf("invalid HS color %q (use hue 0-360, saturation 0-100)", value)
+		}
+		return colorData(caps, "hs_color", hs)
+
+	case action == "xy":
+		xy, err := parseComponents(value, 2, 0, 1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid XY color %q (use two values from 0 to 1)", value)
+		}
+		return colorData(caps, "xy_color", xy)
+
+	case strings.HasPrefix(action, "#"):
+		rgb, err := parseHexColor(action)
+		if err != nil {
+			return nil, err
+		}
+		return colorData(caps, "rgb_color", rgb)
+
+	case action == "color" || action == "colour":
+		if strings.HasPrefix(value, "#") {
+			rgb, err := parseHexColor(value)
+			if err != nil {
+				return nil, err
+			}
+			return colorData(caps, "rgb_color", rgb)
+		}
+		if preset, ok := colorTempPresets[strings.ToLower(value)]; ok {
+			return colorTemp(caps, preset)
+		}
+		return colorData(caps, "color_name", strings.ToLower(strings.ReplaceAll(value, " ", "")))
+
+	case cssColors[action]:
+		return colorData(caps, "color_name", action)
+
+	case action == "effect":
+		if !caps.Has(entity.LightFeatureEffect) {
+			return nil, caps.Unsupported("effects", "")
+		}
+		effect, err := caps.MatchOption("effect", value, caps.Effects)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"effect": effect}, nil
+
+	case action == "flash":
+		if !caps.Has(entity.LightFeatureFlash) {
+			return nil, caps.Unsupported("flash", "")
+		}
+		length := "short"
+		if value != "" {
+			length = strings.ToLower(value)
+		}
+		if length != "short" && length != "long" {
+			return nil, fmt.Errorf("flash must be short or long, got %q", value)
+		}
+		return map[string]interface{}{"flash": length}, nil
+
+	default:
+		return nil, caps.Unsupported(action, "")
+	}
+}
+
+func percentBrightness(value string) (map[string]interface{}, error) {
+	percent, err := entity.ParseNumericValue(strings.TrimSuffix(value, "%"))
+	if err != nil || percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("invalid brightness %q (use 0-100%%)", value)
+	}
+	return map[string]interface{}{"brightness_pct": int(percent)}, nil
+}
+
+func relativeBrightness(value string) (map[string]interface{}, error) {
+	step, err := entity.ParseNumericValue(strings.TrimSuffix(value, "%"))
+	if err != nil || step < -100 || step > 100 {
+		return nil, fmt.Errorf("invalid brightness step %q (use -100 to +100)", value)
+	}
+	return map[string]interface{}{"brightness_step_pct": int(step)}, nil
+}
+
+func colorTemp(caps entity.Capabilities, kelvin int) (map[string]interface{}, error) {
+	if !caps.SupportsColorTemp() {
+		return nil, caps.Unsupported("color temperature", "")
+	}
+	if err := caps.CheckRange("color temperature", float64(kelvin), caps.MinKelvin, caps.MaxKelvin); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"color_temp_kelvin": kelvin}, nil
+}
+
+func colorData(caps entity.Capabilities, field string, value interface{}) (map[string]interface{}, error) {
+	if !caps.SupportsColor() {
+		return nil, caps.Unsupported("color", "")
+	}
+	return map[string]interface{}{field: value}, nil
+}
+
+// parseColorTemp reads "2700", "2700K" or "370mired"; bare values below 1000
+// are taken as mireds.
+func parseColorTemp(value string) (int, error) {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	mired := strings.HasSuffix(lower, "mired") || strings.HasSuffix(lower, "mireds")
+	number := strings.TrimRight(lower, "kmireds")
+
+	parsed, err := entity.ParseNumericValue(number)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("invalid color temperature %q (use kelvin like 2700K, mireds like 370mired, or warm/neutral/cool/daylight)", value)
+	}
+	if mired || parsed < 1000 {
+		return int(math.Round(1e6 / parsed)), nil
+	}
+	return int(parsed), nil
+}
+
+// parseComponents reads n comma-separated numbers within [low, high].
+func parseComponents(value string, n int, low, high float64) ([]float64, error) {
+	parts := strings.Split(strings.Trim(value, "() "), ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d comma-separated values", n)
+	}
+
+	components := make([]float64, n)
+	for i, part := range parts {
+		component, err := entity.ParseNumericValue(strings.TrimSpace(part))
+		if err != nil || component < low || component > high {
+			return nil, fmt.Errorf("values must be between %g and %g", low, high)
+		}
+		components[i] = component
+	}
+	return components, nil
+}
+
+func parseHexColor(value string) ([]float64, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("invalid hex color %q (use #rrggbb)", value)
+	}
+
+	rgb := make([]float64, 3)
+	for i := range rgb {
+		component, err := strconv.ParseUint(hex[i*2:i*2+2], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex color %q (use #rrggbb)", value)
+		}
+		rgb[i] = float64(component)
+	}
+	return rgb, nil
+}
+
+// withTransition adds a transition in seconds, given as "2" or "1.5s".
+func withTransition(caps entity.Capabilities, serviceData map[string]interface{}, value string) (map[string]interface{}, error) {
+	if !caps.Has(entity.LightFeatureTransition) {
+		return nil, caps.Unsupported("transitions", "")
+	}
+
+	seconds, err := entity.ParseNumericValue(value)
+	if err != nil {
+		duration, durationErr := time.ParseDuration(value)
+		if durationErr != nil {
+			return nil, fmt.Errorf("invalid transition %q (use seconds like 2 or a duration like 1.5s)", value)
+		}
+		seconds = duration.Seconds()
+	}
+	if seconds < 0 || seconds > 300 {
+		return nil, fmt.Errorf("transition must be between 0 and 300 seconds")
+	}
+
+	serviceData["transition"] = seconds
+	return serviceData, nil
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// cssColors are the color names Home Assistant accepts as color_name.
+var cssColors = map[string]bool{
+	"aliceblue": true, "antiquewhite": true, "aqua": true, "aquamarine": true, "azure": true,
+	"beige": true, "bisque": true, "black": true, "blanchedalmond": true, "blue": true,
+	"blueviolet": true, "brown": true, "burlywood": true, "cadetblue": true, "chartreuse": true,
+	"chocolate": true, "coral": true, "cornflowerblue": true, "cornsilk": true, "crimson": true,
+	"cyan": true, "darkblue": true, "darkcyan": true, "darkgoldenrod": true, "darkgray": true,
+	"darkgreen": true, "darkgrey": true, "darkkhaki": true, "darkmagenta": true, "darkolivegreen": true,
+	"darkorange": true, "darkorchid": true, "darkred": true, "darksalmon": true, "darkseagreen": true,
+	"darkslateblue": true, "darkslategray": true, "darkslategrey": true, "darkturquoise": true, "darkviolet": true,
+	"deeppink": true, "deepskyblue": true, "dimgray": true, "dimgrey": true, "dodgerblue": true,
+	"firebrick": true, "floralwhite": true, "forestgreen": true, "fuchsia": true, "gainsboro": true,
+	"ghostwhite": true, "gold": true, "goldenrod": true, "gray": true, "green": true,
+	"greenyellow": true, "grey": true, "homeassistant": true, "honeydew": true, "hotpink": true,
+	"indianred": true, "indigo": true, "ivory": true, "khaki": true, "lavender": true,
+	"lavenderblush": true, "lawngreen": true, "lemonchiffon": true, "lightblue": true, "lightcoral": true,
+	"lightcyan": true, "lightgoldenrodyellow": true, "lightgray": true, "lightgreen": true, "lightgrey": true,
+	"lightpink": true, "lightsalmon": true, "lightseagreen": true, "lightskyblue": true, "lightslategray": true,
+	"lightslategrey": true, "lightsteelblue": true, "lightyellow": true, "lime": true, "limegreen": true,
+	"linen": true, "magenta": true, "maroon": true, "mediumaquamarine": true, "mediumblue": true,
+	"mediumorchid": true, "mediumpurple": true, "mediumseagreen": true, "mediumslateblue": true, "mediumspringgreen": true,
+	"mediumturquoise": true, "mediumvioletred": true, "midnightblue": true, "mintcream": true, "mistyrose": true,
+	"moccasin": true, "navajowhite": true, "navy": true, "navyblue": true, "oldlace": true,
+	"olive": true, "olivedrab": true, "orange": true, "orangered": true, "orchid": true,
+	"palegoldenrod": true, "palegreen": true, "paleturquoise": true, "palevioletred": true, "papayawhip": true,
+	"peachpuff": true, "peru": true, "pink": true, "plum": true, "powderblue": true,
+	"purple": true, "red": true, "rosybrown": true, "royalblue": true, "saddlebrown": true,
+	"salmon": true, "sandybrown": true, "seagreen": true, "seashell": true, "sienna": true,
+	"silver": true, "skyblue": true, "slateblue": true, "slategray": true, "slategrey": true,
+	"snow": true, "springgreen": true, "steelblue": true, "tan": true, "teal": true,
+	"thistle": true, "tomato": true, "turquoise": true, "violet": true, "wheat": true,
+	"whitesmoke": true, "yellow": true, "yellowgreen": true,
+}
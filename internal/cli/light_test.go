@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func lightCapabilities(features int, modes ...interface{}) entity.Capabilities {
+	return entity.CapabilitiesOf(client.EntityState{
+		EntityID: "light.living",
+		Attributes: map[string]interface{}{
+			"supported_features":    float64(features),
+			"supported_color_modes": modes,
+			"min_color_temp_kelvin": float64(2000),
+			"max_color_temp_kelvin": float64(6500),
+			"effect_list":           []interface{}{"Colorloop", "Random"},
+		},
+	})
+}
+
+func TestLightServiceData(t *testing.T) {
+	full := lightCapabilities(entity.LightFeatureEffect|entity.LightFeatureFlash|entity.LightFeatureTransition, "color_temp", "hs")
+
+	tests := []struct {
+		action string
+		value  string
+		want   map[string]interface{}
+	}{
+		{"50", "", map[string]interface{}{"brightness_pct": 50}},
+		{"75%", "", map[string]interface{}{"brightness_pct": 75}},
+		{"100", "", map[string]interface{}{"brightness_pct": 100}},
+		{"brightness", "128", map[string]interface{}{"brightness": 128}},
+		{"brightness", "40%", map[string]interface{}{"brightness_pct": 40}},
+		{"dim", "", map[string]interface{}{"brightness_step_pct": -25}},
+		{"bright", "10", map[string]interface{}{"brightness_step_pct": 10}},
+		{"+20", "", map[string]interface{}{"brightness_step_pct": 20}},
+		{"-15%", "", map[string]interface{}{"brightness_step_pct": -15}},
+		{"warm", "", map[string]interface{}{"color_temp_kelvin": 2700}},
+		{"kelvin", "3500K", map[string]interface{}{"color_temp_kelvin": 3500}},
+		{"kelvin", "250", map[string]interface{}{"color_temp_kelvin": 4000}},
+		{"red", "", map[string]interface{}{"color_name": "red"}},
+		{"color", "Dark Orange", map[string]interface{}{"color_name": "darkorange"}},
+		{"rgb", "255,0,0", map[string]interface{}{"rgb_color": []float64{255, 0, 0}}},
+		{"#f80", "", map[string]interface{}{"rgb_color": []float64{255, 136, 0}}},
+		{"hs", "30,100", map[string]interface{}{"hs_color": []float64{30, 100}}},
+		{"xy", "0.3,0.35", map[string]interface{}{"xy_color": []float64{0.3, 0.35}}},
+		{"effect", "colorloop", map[string]interface{}{"effect": "Colorloop"}},
+		{"flash", "long", map[string]interface{}{"flash": "long"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" "+tt.value, func(t *testing.T) {
+			got, err := lightServiceData(full, tt.action, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLightServiceDataChecksCapabilities(t *testing.T) {
+	tests := []struct {
+		name   string
+		caps   entity.Capabilities
+		action string
+		value  string
+	}{
+		{"brightness on on/off bulb", lightCapabilities(0, "onoff"), "50", ""},
+		{"dim on on/off bulb", lightCapabilities(0, "onoff"), "dim", ""},
+		{"color on white bulb", lightCapabilities(0, "color_temp"), "red", ""},
+		{"color temp on rgb bulb", lightCapabilities(0, "hs"), "warm", ""},
+		{"kelvin out of range", lightCapabilities(0, "color_temp"), "kelvin", "9000"},
+		{"effect without support", lightCapabilities(0, "hs"), "effect", "Colorloop"},
+		{"unknown effect", lightCapabilities(entity.LightFeatureEffect, "hs"), "effect", "strobe"},
+		{"flash without support", lightCapabilities(0, "hs"), "flash", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := lightServiceData(tt.caps, tt.action, tt.value)
+			if !errors.Is(err, entity.ErrNotSupported) {
+				t.Errorf("expected ErrNotSupported, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithTransition(t *testing.T) {
+	caps := lightCapabilities(entity.LightFeatureTransition, "brightness")
+
+	for _, value := range []string{"2", "2s", "2000ms"} {
+		data, err := withTransition(caps, map[string]interface{}{}, value)
+		if err != nil || data["transition"] != 2.0 {
+			t.Errorf("withTransition(%q) = %v (err=%v), expected 2 seconds", value, data, err)
+		}
+	}
+
+	if _, err := withTransition(lightCapabilities(0, "brightness"), map[string]interface{}{}, "2"); !errors.Is(err, entity.ErrNotSupported) {
+		t.Errorf("expected transitions to be rejected, got %v", err)
+	}
+}
+
+func TestBareBrightnessIsPercent(t *testing.T) {
+	lamp := client.EntityState{
+		EntityID: "light.living",
+		State:    "on",
+		Attributes: map[string]interface{}{
+			"friendly_name":         "Living Lamp",
+			"supported_color_modes": []interface{}{"brightness"},
+		},
+	}
+
+	commander, fake := newRecordingCommander(t, lamp)
+	if err := commander.Execute([]string{"living", "lights", "100"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call := fake.lastCall(t)
+	if call.Domain != "light" || call.Service != "turn_on" || call.Data["brightness_pct"] != 100.0 {
+		t.Errorf("expected light.turn_on with brightness_pct 100, got %s.%s %v", call.Domain, call.Service, call.Data)
+	}
+
+	if err := commander.Execute([]string{"living", "lights", "101"}); err == nil {
+		t.Error("expected 101 to be rejected as a percentage")
+	}
+	if n := fake.callCount(); n != 1 {
+		t.Errorf("expected no call for 101, got %d calls", n)
+	}
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const maxMacroDepth = 16
+
+var macroParamPattern = regexp.MustCompile(`\$(\d+|@)`)
+
+func (c *Commander) handleMacroCommand(name string, args []string) error {
+	if err := c.config.ValidateMacros(); err != nil {
+		return fmt.Errorf("invalid macros in config: %w", err)
+	}
+
+	dryRun := false
+	var macroArgs []string
+	for _, arg := range args {
+		if arg == "--dry-run" {
+			dryRun = true
+			continue
+		}
+		macroArgs = append(macroArgs, arg)
+	}
+
+	steps, err := c.expandMacro(name, macroArgs, 0)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	if dryRun {
+		return c.describeMacro(name, steps)
+	}
+
+	c.reuseStates()
+
+	for i, step := range steps {
+		if err := c.Execute(step); err != nil {
+			return fmt.Errorf("macro %s step %d (%s): %w", name, i+1, strings.Join(step, " "), err)
+		}
+	}
+
+	return nil
+}
+
+// expandMacro substitutes positional parameters into each step and inlines
+// steps that call other macros.
+func (c *Commander) expandMacro(name string, args []string, depth int) ([][]string, error) {
+	if depth > maxMacroDepth {
+		return nil, fmt.Errorf("macro %s nests too deeply", name)
+	}
+
+	var steps [][]string
+	for _, template := range c.config.Macros[name] {
+		tokens, err := splitCommandLine(template)
+		if err != nil {
+			return nil, fmt.Errorf("macro %s: %q: %w", name, template, err)
+		}
+
+		step, err := substituteMacroParams(name, tokens, args)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, isMacro := c.config.Macros[step[0]]; isMacro && !isBuiltinCommand(step[0]) {
+			nested, err := c.expandMacro(step[0], step[1:], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, nested...)
+			continue
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+func substituteMacroParams(name string, tokens, args []string) ([]string, error) {
+	var result []string
+	var missing int
+
+	for _, token := range tokens {
+		if token == "$@" {
+			result = append(result, args...)
+			continue
+		}
+
+		token = macroParamPattern.ReplaceAllStringFunc(token, func(ref string) string {
+			if ref == "$@" {
+				return strings.Join(args, " ")
+			}
+			index, _ := strconv.Atoi(ref[1:])
+			if index < 1 || index > len(args) {
+				if index > missing {
+					missing = index
+				}
+				return ref
+			}
+			return args[index-1]
+		})
+		result = append(result, token)
+	}
+
+	if missing > 0 {
+		return nil, fmt.Errorf("macro %s expects at least %d argument(s), got %d", name, missing, len(args))
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("macro %s has an empty step", name)
+	}
+
+	return result, nil
+}
+
+func (c *Commander) describeMacro(name string, steps [][]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.HomeAssistant.Timeout)
+	defer cancel()
+
+	fmt.Fprintf(c.out, "Macro %s (dry run, nothing will be executed):\n", name)
+	for i, step := range steps {
+		fmt.Fprintf(c.out, "  %d. %s\n", i+1, strings.Join(step, " "))
+		fmt.Fprintf(c.out, "     → %s\n", c.describeStep(ctx, step))
+	}
+
+	return nil
+}
+
+func (c *Commander) describeStep(ctx context.Context, step []string) string {
+	if isBuiltinCommand(step[0]) {
+		return fmt.Sprintf("runs the %s command", step[0])
+	}
+
+	area, entityType, action, value, err := parseEntityArgs(step)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	match, err := c.resolver.ResolveEntity(ctx, area, entityType, "")
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	description := fmt.Sprintf("%s (%s): %s", match.FriendlyName, match.EntityID, action)
+	if value != "" {
+		description += " " + value
+	}
+	return description
+}
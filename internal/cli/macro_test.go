@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+func TestExpandMacro(t *testing.T) {
+	commander := newTestCommander(t)
+	commander.config.Macros = map[string][]string{
+		"movie":  {"$1 lights brightness 40", "$1 blinds position 0", `scene "Movie Time"`},
+		"night":  {"movie bedroom", "hallway lights $2"},
+		"either": {"wait $@"},
+	}
+
+	steps, err := commander.expandMacro("night", []string{"unused", "off"}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"bedroom lights brightness 40",
+		"bedroom blinds position 0",
+		"scene Movie Time",
+		"hallway lights off",
+	}
+	if len(steps) != len(expected) {
+		t.Fatalf("expected %d steps, got %d: %v", len(expected), len(steps), steps)
+	}
+	for i, step := range steps {
+		if got := strings.Join(step, " "); got != expected[i] {
+			t.Errorf("step %d = %q, expected %q", i+1, got, expected[i])
+		}
+	}
+
+	steps, err = commander.expandMacro("either", []string{"front door", "--state", "open"}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps[0]) != 4 || steps[0][1] != "front door" {
+		t.Errorf("expected $@ to splice arguments, got %q", steps[0])
+	}
+
+	if _, err := commander.expandMacro("movie", nil, 0); err == nil {
+		t.Error("expected error for missing macro argument")
+	}
+}
+
+func TestMacroRecursionIsRejected(t *testing.T) {
+	commander := newTestCommander(t)
+	commander.config.Macros = map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+	}
+
+	err := commander.Execute([]string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "recursive") {
+		t.Errorf("expected recursion error, got %v", err)
+	}
+}
+
+func TestMacroReusesStates(t *testing.T) {
+	light := func(entityID, name string) client.EntityState {
+		return client.EntityState{EntityID: entityID, State: "off", Attributes: map[string]interface{}{"friendly_name": name}}
+	}
+	commander, fake := newRecordingCommander(t, light("light.living_lamp", "Living Lamp"), light("light.kitchen_lamp", "Kitchen Lamp"))
+	commander.config.Macros = map[string][]string{"evening": {"living lights on", "kitchen lights on"}}
+
+	if err := commander.Execute([]string{"evening"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.callCount() != 2 {
+		t.Fatalf("expected both steps to run, got %d calls", fake.callCount())
+	}
+	if fake.statesFetches != 1 {
+		t.Errorf("expected the steps to share one entity list, got %d fetches", fake.statesFetches)
+	}
+}
@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+// defaultMediaType is the media_content_type used when --type is not given.
+const defaultMediaType = "music"
+
+func (c *Commander) handleMediaPlayer(ctx context.Context, entityID, action, value, mediaType string) error {
+	// Relative volume changes start from the current level, not a cached one.
+	state, err := client.Uncached(c.client).GetState(ctx, entityID)
+	if err != nil {
+		return fmt.Errorf("failed to get state of %s: %w", entityID, err)
+	}
+
+	service, serviceData, err := mediaServiceData(*state, strings.ToLower(action), value, mediaType)
+	if err != nil {
+		return err
+	}
+
+	if service == "join" {
+		members, err := c.resolveMediaPlayers(ctx, serviceData["group_members"].([]string))
+		if err != nil {
+			return err
+		}
+		serviceData["group_members"] = members
+	}
+
+	target := map[string]interface{}{
+		"entity_id": entityID,
+	}
+
+	_, err = c.client.CallService(ctx, "media_player", service, target, serviceData)
+	return err
+}
+
+// mediaServiceData maps an action and optional value to a media_player
+// service call:
+//
+//	play, pause, play_pause, stop, next, previous
+//	volume 30, volume +5, volume up|down      volume in percent
+//	mute [on|off], unmute
+//	source <name>, sound_mode <name>          from the player's lists
+//	play <id>, play_media <id>                with --type (default music)
+//	shuffle [on|off], repeat off|all|one
+//	join <player>[, <player>...], unjoin
+//
+// For join, group_members holds the names as given, to be resolved.
+func mediaServiceData(state client.EntityState, action, value, mediaType string) (string, map[string]interface{}, error) {
+	caps := entity.CapabilitiesOf(state)
+	none := map[string]interface{}{}
+
+	simple := map[string]struct {
+		service string
+		feature int
+	}{
+		"pause":      {"media_pause", entity.MediaFeaturePause},
+		"play_pause": {"media_play_pause", entity.MediaFeaturePause | entity.MediaFeaturePlay},
+		"stop":       {"media_stop", entity.MediaFeatureStop},
+		"next":       {"media_next_track", entity.MediaFeatureNextTrack},
+		"skip":       {"media_next_track", entity.MediaFeatureNextTrack},
+		"previous":   {"media_previous_track", entity.MediaFeaturePreviousTrack},
+		"prev":       {"media_previous_track", entity.MediaFeaturePreviousTrack},
+		"unjoin":     {"unjoin", entity.MediaFeatureGrouping},
+	}
+	if call, ok := simple[action]; ok {
+		if !caps.Has(call.feature) {
+			return "", nil, caps.Unsupported(action, "")
+		}
+		return call.service, none, nil
+	}
+
+	switch action {
+	case "play":
+		if value != "" {
+			return mediaServiceData(state, "play_media", value, mediaType)
+		}
+		if !caps.Has(entity.MediaFeaturePlay) {
+			return "", nil, caps.Unsupported("play", "")
+		}
+		return "media_play", none, nil
+
+	case "play_media":
+		if !caps.Has(entity.MediaFeaturePlayMedia) {
+			return "", nil, caps.Unsupported("play_media", "it cannot play media by ID")
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a media ID or URL", action)
+		}
+		if mediaType == "" {
+			mediaType = defaultMediaType
+		}
+		return "play_media", map[string]interface{}{
+			"media_content_id":   value,
+			"media_content_type": mediaType,
+		}, nil
+
+	case "volume", "vol":
+		return volumeData(caps, state, value)
+
+	case "mute", "unmute":
+		if !caps.Has(entity.MediaFeatureVolumeMute) {
+			return "", nil, caps.Unsupported("mute", "")
+		}
+		muted := action == "mute"
+		if value != "" {
+			var err error
+			if muted, err = parseOnOff(value); err != nil {
+				return "", nil, fmt.Errorf("invalid mute value: %s (use on or off)", value)
+			}
+			muted = muted == (action == "mute")
+		}
+		return "volume_mute", map[string]interface{}{"is_volume_muted": muted}, nil
+
+	case "source", "input":
+		if !caps.Has(entity.MediaFeatureSelectSource) {
+			return "", nil, caps.Unsupported("source", "")
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		source, err := caps.MatchOption("source", value, caps.Sources)
+		if err != nil {
+			return "", nil, err
+		}
+		return "select_source", map[string]interface{}{"source": source}, nil
+
+	case "sound_mode", "sound":
+		if !caps.Has(entity.MediaFeatureSelectSoundMode) {
+			return "", nil, caps.Unsupported("sound_mode", "")
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		mode, err := caps.MatchOption("sound_mode", value, caps.SoundModes)
+		if err != nil {
+			return "", nil, err
+		}
+		return "select_sound_mode", map[string]interface{}{"sound_mode": mode}, nil
+
+	case "shuffle":
+		if !caps.Has(entity.MediaFeatureShuffleSet) {
+			return "", nil, caps.Unsupported("shuffle", "")
+		}
+		shuffle := true
+		if value != "" {
+			var err error
+			if shuffle, err = parseOnOff(value); err != nil {
+				return "", nil, fmt.Errorf("invalid shuffle value: %s (use on or off)", value)
+			}
+		}
+		return "shuffle_set", map[string]interface{}{"shuffle": shuffle}, nil
+
+	case "repeat":
+		if !caps.Has(entity.MediaFeatureRepeatSet) {
+			return "", nil, caps.Unsupported("repeat", "")
+		}
+		repeat := strings.ToLower(value)
+		switch repeat {
+		case "":
+			repeat = "all"
+		case "on":
+			repeat = "all"
+		case "off", "all", "one":
+		default:
+			return "", nil, fmt.Errorf("invalid repeat value: %s (use off, all or one)", value)
+		}
+		return "repeat_set", map[string]interface{}{"repeat": repeat}, nil
+
+	case "join", "group":
+		if !caps.Has(entity.MediaFeatureGrouping) {
+			return "", nil, caps.Unsupported("join", "it cannot be grouped with other players")
+		}
+		members := splitMembers(value)
+		if len(members) == 0 {
+			return "", nil, fmt.Errorf("%s needs the players to group with", action)
+		}
+		return "join", map[string]interface{}{"group_members": members}, nil
+	}
+
+	return "", nil, caps.Unsupported(action, "")
+}
+
+// volumeData sets the volume to a percentage, moves it by +N/-N percent, or
+// steps it up or down.
+func volumeData(caps entity.Capabilities, state client.EntityState, value string) (string, map[string]interface{}, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	switch value {
+	case "":
+		return "", nil, fmt.Errorf("volume needs a value")
+	case "up", "down":
+		if !caps.Has(entity.MediaFeatureVolumeStep | entity.MediaFeatureVolumeSet) {
+			return "", nil, caps.Unsupported("volume", "")
+		}
+		return "volume_" + value, map[string]interface{}{}, nil
+	}
+
+	if !caps.Has(entity.MediaFeatureVolumeSet) {
+		return "", nil, caps.Unsupported("volume "+value, "it can only step the volume up or down")
+	}
+
+	raw := strings.TrimSuffix(value, "%")
+	percent, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid volume value: %s", value)
+	}
+
+	if strings.HasPrefix(raw, "+") || strings.HasPrefix(raw, "-") {
+		current, ok := state.Attributes["volume_level"].(float64)
+		if !ok {
+			return "", nil, fmt.Errorf("%s does not report its volume; use an absolute value or volume up|down", state.EntityID)
+		}
+		percent = math.Max(0, math.Min(100, current*100+percent))
+	} else if percent < 0 || percent > 100 {
+		return "", nil, fmt.Errorf("volume must be between 0 and 100")
+	}
+
+	return "volume_set", map[string]interface{}{"volume_level": math.Round(percent) / 100}, nil
+}
+
+// splitMembers splits "kitchen speaker, media_player.den and bedroom" into
+// player names.
+func splitMembers(value string) []string {
+	var members []string
+	for _, part := range strings.Split(strings.ReplaceAll(value, " and ", ","), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			members = append(members, part)
+		}
+	}
+	return members
+}
+
+// resolveMediaPlayers turns player names into entity IDs.
+func (c *Commander) resolveMediaPlayers(ctx context.Context, names []string) ([]string, error) {
+	var ids []string
+	for _, name := range names {
+		if strings.HasPrefix(name, "media_player.") {
+			ids = append(ids, name)
+			continue
+		}
+
+		match, err := c.resolver.ResolveEntity(ctx, "", "", name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %q: %w", name, err)
+		}
+		if match.Domain != "media_player" {
+			return nil, fmt.Errorf("%q matched %s, which is not a media player", name, match.EntityID)
+		}
+		ids = append(ids, match.EntityID)
+	}
+	return ids, nil
+}
+
+// printNowPlaying shows what a media player is playing in entity status.
+func printNowPlaying(w io.Writer, state client.EntityState) {
+	np, ok := entity.NowPlayingOf(state, time.Now())
+	if !ok {
+		return
+	}
+
+	fmt.Fprintln(w, "\nNow Playing:")
+	fields := [][2]string{
+		{"Title", np.Title},
+		{"Artist", np.Artist},
+		{"Album", np.Album},
+		{"Position", np.Progress()},
+		{"Source", np.Source},
+		{"Volume", np.VolumeText()},
+	}
+	for _, field := range fields {
+		if field[1] != "" {
+			fmt.Fprintf(w, "  %s: %s\n", field[0], field[1])
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func mediaPlayerState(features int) client.EntityState {
+	return client.EntityState{
+		EntityID: "media_player.living_room",
+		State:    "playing",
+		Attributes: map[string]interface{}{
+			"supported_features": float64(features),
+			"volume_level":       0.4,
+			"source_list":        []interface{}{"TV", "Spotify", "Line In"},
+			"sound_mode_list":    []interface{}{"Music", "Movie"},
+		},
+	}
+}
+
+func TestMediaServiceData(t *testing.T) {
+	speaker := mediaPlayerState(entity.MediaFeaturePause | entity.MediaFeaturePlay | entity.MediaFeatureNextTrack |
+		entity.MediaFeatureVolumeSet | entity.MediaFeatureVolumeMute | entity.MediaFeatureSelectSource |
+		entity.MediaFeatureSelectSoundMode | entity.MediaFeaturePlayMedia | entity.MediaFeatureShuffleSet |
+		entity.MediaFeatureRepeatSet | entity.MediaFeatureGrouping)
+
+	tests := []struct {
+		action      string
+		value       string
+		mediaType   string
+		wantService string
+		want        map[string]interface{}
+	}{
+		{"play", "", "", "media_play", map[string]interface{}{}},
+		{"pause", "", "", "media_pause", map[string]interface{}{}},
+		{"next", "", "", "media_next_track", map[string]interface{}{}},
+		{"volume", "25", "", "volume_set", map[string]interface{}{"volume_level": 0.25}},
+		{"volume", "30%", "", "volume_set", map[string]interface{}{"volume_level": 0.3}},
+		{"volume", "+10", "", "volume_set", map[string]interface{}{"volume_level": 0.5}},
+		{"volume", "-80", "", "volume_set", map[string]interface{}{"volume_level": 0.0}},
+		{"volume", "up", "", "volume_up", map[string]interface{}{}},
+		{"mute", "", "", "volume_mute", map[string]interface{}{"is_volume_muted": true}},
+		{"mute", "off", "", "volume_mute", map[string]interface{}{"is_volume_muted": false}},
+		{"unmute", "", "", "volume_mute", map[string]interface{}{"is_volume_muted": false}},
+		{"source", "spotify", "", "select_source", map[string]interface{}{"source": "Spotify"}},
+		{"source", "line in", "", "select_source", map[string]interface{}{"source": "Line In"}},
+		{"sound_mode", "movie", "", "select_sound_mode", map[string]interface{}{"sound_mode": "Movie"}},
+		{"play", "https://example.com/stream.mp3", "", "play_media", map[string]interface{}{
+			"media_content_id": "https://example.com/stream.mp3", "media_content_type": "music"}},
+		{"play_media", "spotify:playlist:1", "playlist", "play_media", map[string]interface{}{
+			"media_content_id": "spotify:playlist:1", "media_content_type": "playlist"}},
+		{"shuffle", "", "", "shuffle_set", map[string]interface{}{"shuffle": true}},
+		{"repeat", "one", "", "repeat_set", map[string]interface{}{"repeat": "one"}},
+		{"repeat", "", "", "repeat_set", map[string]interface{}{"repeat": "all"}},
+		{"join", "kitchen, media_player.den and bedroom", "", "join", map[string]interface{}{
+			"group_members": []string{"kitchen", "media_player.den", "bedroom"}}},
+		{"unjoin", "", "", "unjoin", map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" "+tt.value, func(t *testing.T) {
+			service, got, err := mediaServiceData(speaker, tt.action, tt.value, tt.mediaType)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service != tt.wantService {
+				t.Errorf("expected media_player.%s, got media_player.%s", tt.wantService, service)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMediaServiceDataErrors(t *testing.T) {
+	// A basic speaker can play, pause and step its volume, nothing else.
+	basic := mediaPlayerState(entity.MediaFeaturePlay | entity.MediaFeaturePause | entity.MediaFeatureVolumeStep)
+
+	for _, args := range [][2]string{{"next", ""}, {"volume", "50"}, {"source", "TV"}, {"join", "kitchen"}, {"shuffle", ""}} {
+		if _, _, err := mediaServiceData(basic, args[0], args[1], ""); !errors.Is(err, entity.ErrNotSupported) {
+			t.Errorf("%s %s: expected ErrNotSupported, got %v", args[0], args[1], err)
+		}
+	}
+
+	full := mediaPlayerState(entity.MediaFeatureVolumeSet | entity.MediaFeatureSelectSource | entity.MediaFeatureRepeatSet)
+	for _, args := range [][2]string{{"volume", "150"}, {"volume", "loud"}, {"source", "Bluetooth"}, {"repeat", "twice"}} {
+		if _, _, err := mediaServiceData(full, args[0], args[1], ""); err == nil {
+			t.Errorf("%s %s: expected an error", args[0], args[1])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/quinncuatro/hass-cli/internal/config"
+)
+
+const migrateUsage = "usage: config migrate [--dry-run]"
+
+func (c *Commander) handleMigrateCommand(args []string) error {
+	positional, flags, err := splitFlags(args)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if len(positional) != 0 {
+		return withExitCode(ExitUsage, fmt.Errorf(migrateUsage))
+	}
+
+	path, err := config.Path()
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %w", err)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("no config file at %s; run 'hass config init' to create one", path)
+	}
+
+	dryRun := flags.has("dry-run")
+	report, err := config.MigrateFile(path, dryRun)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		fmt.Fprintf(c.out, "✓ %s is already at version %d\n", path, config.CurrentVersion)
+		return nil
+	}
+
+	printMigration(c.out, path, report)
+	if dryRun {
+		fmt.Fprintln(c.out, "Dry run: no files were changed")
+		return nil
+	}
+
+	fmt.Fprintf(c.out, "✓ Backup written to %s\n", report.Backup)
+	fmt.Fprintf(c.out, "✓ %s migrated to version %d\n", path, report.To)
+	return nil
+}
+
+// PrintConfigMigration reports an upgrade Load applied to config.yaml.
+func PrintConfigMigration(cfg *config.Config) {
+	report := cfg.Migration()
+	if report == nil {
+		return
+	}
+
+	path, _ := config.Path()
+	printMigration(os.Stderr, path, report)
+	fmt.Fprintf(os.Stderr, "The original was saved to %s\n", report.Backup)
+}
+
+func printMigration(w io.Writer, path string, report *config.MigrationReport) {
+	fmt.Fprintf(w, "Migrating %s from version %d to %d:\n", path, report.From, report.To)
+	for _, change := range report.Changes {
+		fmt.Fprintf(w, "  • %s\n", change)
+	}
+	if len(report.Changes) == 0 {
+		fmt.Fprintf(w, "  • add version: %d\n", report.To)
+	}
+}
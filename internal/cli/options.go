@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Options holds settings that come from global flags rather than the
+// config file.
+type Options struct {
+	Version   string
+	NoCache   bool
+	Debug     bool
+	TraceFile string
+}
+
+// ParseGlobalFlags removes global flags from args, wherever they appear
+// before a bare "--", and returns them as Options. HASS_DEBUG enables debug
+// logging as if --debug had been given.
+func ParseGlobalFlags(args []string) (Options, []string, error) {
+	opts := Options{Debug: envEnabled("HASS_DEBUG")}
+	remaining := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			remaining = append(remaining, args[i:]...)
+			break
+		}
+
+		switch {
+		case arg == "--no-cache":
+			opts.NoCache = true
+		case arg == "--debug":
+			opts.Debug = true
+		case arg == "--trace-file":
+			if i+1 >= len(args) {
+				return opts, nil, withExitCode(ExitUsage, fmt.Errorf("--trace-file requires a path"))
+			}
+			i++
+			opts.TraceFile = args[i]
+		case strings.HasPrefix(arg, "--trace-file="):
+			opts.TraceFile = strings.TrimPrefix(arg, "--trace-file=")
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+
+	return opts, remaining, nil
+}
+
+func envEnabled(name string) bool {
+	switch strings.ToLower(os.Getenv(name)) {
+	case "", "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/config"
+)
+
+type configReload struct {
+	config *config.Config
+	err    error
+}
+
+// watchConfig re-reads config.yaml in the background while a long-running
+// mode is active. Reloads are queued for applyConfigReload so the config is
+// only swapped between commands.
+func (c *Commander) watchConfig(ctx context.Context) {
+	reloads := make(chan configReload, 1)
+	c.reloads = reloads
+
+	go config.Watch(ctx, c.config, func(cfg *config.Config, err error) {
+		// Only the newest result matters.
+		select {
+		case <-reloads:
+		default:
+		}
+		reloads <- configReload{config: cfg, err: err}
+	})
+}
+
+// applyConfigReload swaps in a pending reload, reporting what happened.
+func (c *Commander) applyConfigReload() {
+	select {
+	case reload := <-c.reloads:
+		if reload.err != nil {
+			fmt.Fprintf(c.out, "✗ Config not reloaded, keeping the current settings: %v\n", reload.err)
+			return
+		}
+
+		restart := c.config.Reload(reload.config)
+		fmt.Fprintln(c.out, "✓ Config reloaded")
+		if len(restart) > 0 {
+			fmt.Fprintf(c.out, "  Restart hass to apply changes to %s\n", strings.Join(restart, ", "))
+		}
+	default:
+	}
+}
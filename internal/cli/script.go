@@ -0,0 +1,395 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type stepKind int
+
+const (
+	stepCommand stepKind = iota
+	stepSleep
+	stepParallel
+)
+
+type scriptStep struct {
+	line     int
+	kind     stepKind
+	text     string
+	args     []string
+	duration time.Duration
+	children []scriptStep
+}
+
+type stepResult struct {
+	step     scriptStep
+	err      error
+	skipped  bool
+	duration time.Duration
+}
+
+var scriptVariablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+func (c *Commander) handleRunCommand(args []string) error {
+	positional, flags, err := splitFlags(args, "var")
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	if len(positional) != 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("usage: run <script|-> [--var name=value] [--continue-on-error]"))
+	}
+
+	vars := make(map[string]string)
+	for _, assignment := range flags["var"] {
+		name, value, ok := strings.Cut(assignment, "=")
+		if !ok || name == "" {
+			return withExitCode(ExitUsage, fmt.Errorf("invalid --var %q (expected name=value)", assignment))
+		}
+		vars[name] = value
+	}
+
+	var input io.Reader = os.Stdin
+	if positional[0] != "-" {
+		file, err := os.Open(positional[0])
+		if err != nil {
+			return fmt.Errorf("failed to open script: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+		input = file
+	}
+
+	steps, err := parseScript(input, vars)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	// Every line resolves names against the same entity list instead of
+	// re-fetching all states per command.
+	c.reuseStates()
+
+	start := time.Now()
+	results := c.runSteps(steps, flags.has("continue-on-error"))
+	return c.reportScriptResults(results, time.Since(start))
+}
+
+func parseScript(input io.Reader, vars map[string]string) ([]scriptStep, error) {
+	scanner := bufio.NewScanner(input)
+
+	var steps []scriptStep
+	var block *scriptStep
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if line == "}" {
+			if block == nil {
+				return nil, fmt.Errorf("line %d: unexpected '}'", lineNumber)
+			}
+			steps = append(steps, *block)
+			block = nil
+			continue
+		}
+
+		tokens, err := splitCommandLine(expandVariables(line, vars))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		if tokens[0] == "parallel" {
+			if block != nil {
+				return nil, fmt.Errorf("line %d: parallel blocks cannot be nested", lineNumber)
+			}
+			if len(tokens) != 2 || tokens[1] != "{" {
+				return nil, fmt.Errorf("line %d: expected 'parallel {'", lineNumber)
+			}
+			block = &scriptStep{line: lineNumber, kind: stepParallel, text: "parallel"}
+			continue
+		}
+
+		if tokens[0] == "set" {
+			if block != nil {
+				return nil, fmt.Errorf("line %d: variables cannot be set inside a parallel block", lineNumber)
+			}
+			if err := parseAssignment(tokens[1:], vars); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			continue
+		}
+
+		step, err := parseScriptStep(lineNumber, line, tokens)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		if block != nil {
+			block.children = append(block.children, step)
+		} else {
+			steps = append(steps, step)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	if block != nil {
+		return nil, fmt.Errorf("line %d: parallel block is never closed", block.line)
+	}
+
+	return steps, nil
+}
+
+func parseScriptStep(lineNumber int, line string, tokens []string) (scriptStep, error) {
+	step := scriptStep{line: lineNumber, kind: stepCommand, text: line, args: tokens}
+
+	switch tokens[0] {
+	case "sleep":
+		if len(tokens) != 2 {
+			return step, fmt.Errorf("usage: sleep <duration>")
+		}
+		duration, err := parseSleepDuration(tokens[1])
+		if err != nil {
+			return step, err
+		}
+		step.kind = stepSleep
+		step.duration = duration
+	case "wait":
+		// "wait <query> <state>" is shorthand for "wait <query> --state <state>"
+		if len(tokens) < 3 {
+			return step, fmt.Errorf("usage: wait <query> <state>")
+		}
+		if !containsFlag(tokens[1:]) {
+			last := len(tokens) - 1
+			step.args = append(append([]string{}, tokens[:last]...), "--state", tokens[last])
+		}
+	case "run", "shell", "tui":
+		return step, fmt.Errorf("%s cannot be used inside a script", tokens[0])
+	}
+
+	return step, nil
+}
+
+func parseAssignment(tokens []string, vars map[string]string) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("usage: set <name> = <value>")
+	}
+
+	name, value, found := strings.Cut(tokens[0], "=")
+	rest := tokens[1:]
+	if !found {
+		if len(rest) > 0 && rest[0] == "=" {
+			rest = rest[1:]
+		}
+		value = strings.Join(rest, " ")
+	} else if len(rest) > 0 {
+		value = strings.TrimSpace(value + " " + strings.Join(rest, " "))
+	}
+
+	if !scriptVariablePattern.MatchString("$" + name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+
+	vars[name] = value
+	return nil
+}
+
+func parseSleepDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sleep duration %q", value)
+	}
+	return duration, nil
+}
+
+// expandVariables replaces $name and ${name} with script variables, falling
+// back to the environment. Unknown variables are left untouched.
+func expandVariables(line string, vars map[string]string) string {
+	return scriptVariablePattern.ReplaceAllStringFunc(line, func(ref string) string {
+		groups := scriptVariablePattern.FindStringSubmatch(ref)
+		name := groups[1]
+		if name == "" {
+			name = groups[2]
+		}
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// splitCommandLine splits a line into arguments, keeping single- or
+// double-quoted sections together.
+func splitCommandLine(line string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	var quote rune
+	inToken := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	return tokens, nil
+}
+
+func containsFlag(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Commander) runSteps(steps []scriptStep, continueOnError bool) []stepResult {
+	var results []stepResult
+	failed := false
+
+	for _, step := range steps {
+		if failed && !continueOnError {
+			results = append(results, skippedResults(step)...)
+			continue
+		}
+
+		stepResults := c.runStep(step)
+		for _, result := range stepResults {
+			if result.err != nil {
+				failed = true
+			}
+		}
+		results = append(results, stepResults...)
+	}
+
+	return results
+}
+
+func (c *Commander) runStep(step scriptStep) []stepResult {
+	switch step.kind {
+	case stepSleep:
+		time.Sleep(step.duration)
+		return []stepResult{{step: step, duration: step.duration}}
+	case stepParallel:
+		results := make([]stepResult, len(step.children))
+		outputs := make([]bytes.Buffer, len(step.children))
+		var wg sync.WaitGroup
+		for i, child := range step.children {
+			wg.Add(1)
+			go func(i int, child scriptStep) {
+				defer wg.Done()
+				// Each command prints to its own buffer, shown in order
+				// below, and cannot prompt for confirmation or codes.
+				commander := *c
+				commander.out, commander.noPrompt = &outputs[i], true
+				results[i] = commander.runStep(child)[0]
+			}(i, child)
+		}
+		wg.Wait()
+
+		for i := range outputs {
+			_, _ = outputs[i].WriteTo(c.out)
+		}
+		return results
+	default:
+		start := time.Now()
+		err := c.Execute(step.args)
+		return []stepResult{{step: step, err: err, duration: time.Since(start)}}
+	}
+}
+
+func skippedResults(step scriptStep) []stepResult {
+	if step.kind != stepParallel {
+		return []stepResult{{step: step, skipped: true}}
+	}
+
+	results := make([]stepResult, 0, len(step.children))
+	for _, child := range step.children {
+		results = append(results, stepResult{step: child, skipped: true})
+	}
+	return results
+}
+
+func (c *Commander) reportScriptResults(results []stepResult, elapsed time.Duration) error {
+	var succeeded, failed, skipped int
+	for _, result := range results {
+		switch {
+		case result.skipped:
+			skipped++
+		case result.err != nil:
+			failed++
+		default:
+			succeeded++
+		}
+	}
+
+	fmt.Fprintln(c.out)
+	fmt.Fprintf(c.out, "Script summary: %d steps, %d succeeded, %d failed, %d skipped (%s)\n",
+		len(results), succeeded, failed, skipped, elapsed.Round(time.Millisecond))
+
+	for _, result := range results {
+		switch {
+		case result.err != nil:
+			fmt.Fprintf(c.out, "  ✗ line %d: %s: %v\n", result.step.line, result.step.text, result.err)
+		case result.skipped && c.config.Output.Verbosity > 1:
+			fmt.Fprintf(c.out, "  - line %d: %s (skipped)\n", result.step.line, result.step.text)
+		case c.config.Output.Verbosity > 1:
+			fmt.Fprintf(c.out, "  ✓ line %d: %s (%s)\n", result.step.line, result.step.text, result.duration.Round(time.Millisecond))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d script steps failed", failed, len(results))
+	}
+	return nil
+}
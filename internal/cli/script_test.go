@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tokens, err := splitCommandLine(`scene "Movie Time"  now`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tokens) != 3 || tokens[1] != "Movie Time" {
+		t.Errorf("unexpected tokens: %q", tokens)
+	}
+
+	if _, err := splitCommandLine(`scene "Movie`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	script := `
+# Evening routine
+set room = living
+$room lights brightness 40
+sleep 5s
+wait front door closed
+parallel {
+  ${room} blinds position 0
+  kitchen lights off
+}
+scene "Movie Time"
+`
+
+	steps, err := parseScript(strings.NewReader(script), map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(steps) != 5 {
+		t.Fatalf("expected 5 steps, got %d", len(steps))
+	}
+
+	if got := strings.Join(steps[0].args, " "); got != "living lights brightness 40" {
+		t.Errorf("expected variable expansion, got %q", got)
+	}
+
+	if steps[1].kind != stepSleep || steps[1].duration != 5*time.Second {
+		t.Errorf("expected 5s sleep step, got %+v", steps[1])
+	}
+
+	if got := strings.Join(steps[2].args, " "); got != "wait front door --state closed" {
+		t.Errorf("expected wait shorthand to be rewritten, got %q", got)
+	}
+
+	if steps[3].kind != stepParallel || len(steps[3].children) != 2 {
+		t.Fatalf("expected parallel block with 2 children, got %+v", steps[3])
+	}
+	if got := strings.Join(steps[3].children[0].args, " "); got != "living blinds position 0" {
+		t.Errorf("expected expansion inside parallel block, got %q", got)
+	}
+
+	if steps[4].line != 11 || steps[4].args[1] != "Movie Time" {
+		t.Errorf("unexpected final step: %+v", steps[4])
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"unclosed block", "parallel {\nlights on\n"},
+		{"stray brace", "lights on\n}\n"},
+		{"nested block", "parallel {\nparallel {\n}\n}\n"},
+		{"set in block", "parallel {\nset a = b\n}\n"},
+		{"bad sleep", "sleep soon\n"},
+		{"nested run", "run other.hass\n"},
+	}
+
+	for _, test := range tests {
+		if _, err := parseScript(strings.NewReader(test.script), map[string]string{}); err == nil {
+			t.Errorf("%s: expected parse error", test.name)
+		}
+	}
+}
+
+func TestRunStepsStopsOnError(t *testing.T) {
+	commander := newTestCommander(t)
+
+	steps, err := parseScript(strings.NewReader("bogus\nsleep 0\n"), map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results := commander.runSteps(steps, false)
+	if results[0].err == nil || !results[1].skipped {
+		t.Errorf("expected failure followed by a skipped step, got %+v", results)
+	}
+
+	results = commander.runSteps(steps, true)
+	if results[1].skipped || results[1].err != nil {
+		t.Errorf("expected step to run with continue-on-error, got %+v", results[1])
+	}
+}
+
+func TestParallelOutputIsNotInterleaved(t *testing.T) {
+	light := func(entityID, name string) client.EntityState {
+		return client.EntityState{EntityID: entityID, State: "off", Attributes: map[string]interface{}{"friendly_name": name}}
+	}
+	commander := newTestCommander(t, light("light.living_lamp", "Living Lamp"), light("light.kitchen_lamp", "Kitchen Lamp"))
+
+	steps, err := parseScript(strings.NewReader("parallel {\nliving lights on\nkitchen lights on\n}\n"), map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var output bytes.Buffer
+	commander.out = &output
+	for _, result := range commander.runSteps(steps, false) {
+		if result.err != nil {
+			t.Fatalf("unexpected error: %v", result.err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected two lines from each command, got %q", lines)
+	}
+	for i, name := range []string{"Living Lamp", "Living Lamp", "Kitchen Lamp", "Kitchen Lamp"} {
+		if !strings.Contains(lines[i], name) {
+			t.Errorf("expected line %d to be about %s, got %q", i+1, name, lines[i])
+		}
+	}
+}
+
+func TestParallelDoesNotPrompt(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	commander := newTestCommander(t, client.EntityState{
+		EntityID:   "lock.front_door",
+		State:      "locked",
+		Attributes: map[string]interface{}{"friendly_name": "Front Door Lock"},
+	})
+
+	steps, err := parseScript(strings.NewReader("parallel {\nfront lock unlock\n}\n"), map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results := commander.runSteps(steps, false)
+	if !errors.Is(results[0].err, errNotConfirmed) || !strings.Contains(results[0].err.Error(), "parallel") {
+		t.Errorf("expected unlocking in a parallel block to need --yes, got %v", results[0].err)
+	}
+}
@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/config"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+// handleSecurity runs a lock or alarm panel action. It asks for confirmation
+// as preferences.confirm_destructive says, prompts for a code when the entity
+// needs one, and records the outcome in the audit log.
+func (c *Commander) handleSecurity(ctx context.Context, entityID, action, value string, yes bool) error {
+	// Toggle decides from the current state, so a cached one won't do.
+	state, err := client.Uncached(c.client).GetState(ctx, entityID)
+	if err != nil {
+		return fmt.Errorf("failed to get state of %s: %w", entityID, err)
+	}
+
+	service, err := securityService(*state, strings.ToLower(action), strings.ToLower(value))
+	if err != nil {
+		return err
+	}
+
+	err = c.callSecurityService(ctx, *state, service, yes)
+	auditSecurityAction(entityID, service, err)
+	return err
+}
+
+// lockShorthand resolves "hass front door unlock" to the lock: a door may be
+// a lock or a cover, but only a lock can be locked and unlocked.
+func lockShorthand(entityType, action string) string {
+	switch strings.ToLower(action) {
+	case "lock", "unlock":
+		if strings.EqualFold(entityType, "door") || strings.EqualFold(entityType, "doors") {
+			return "lock"
+		}
+	}
+	return entityType
+}
+
+func (c *Commander) callSecurityService(ctx context.Context, state client.EntityState, service string, yes bool) error {
+	if err := c.confirmDestructive(state, service, yes); err != nil {
+		return err
+	}
+
+	serviceData := map[string]interface{}{}
+	if format, ok := codeFormat(state, service); ok {
+		code, err := readCode(state.EntityID, !c.noPrompt)
+		if err != nil {
+			return err
+		}
+		if err := checkCode(format, code); err != nil {
+			return err
+		}
+		serviceData["code"] = code
+	}
+
+	target := map[string]interface{}{
+		"entity_id": state.EntityID,
+	}
+
+	domain := strings.SplitN(state.EntityID, ".", 2)[0]
+	_, err := c.client.CallService(ctx, domain, service, target, serviceData)
+	return err
+}
+
+// securityService maps an action to a lock or alarm_control_panel service:
+//
+//	lock, unlock, open, toggle           locks
+//	arm_home, home, arm away, disarm     alarm panels ("arm" alone is arm_away)
+func securityService(state client.EntityState, action, value string) (string, error) {
+	caps := entity.CapabilitiesOf(state)
+
+	if caps.Domain == "lock" {
+		switch action {
+		case "lock", "unlock":
+			return action, nil
+		case "open":
+			if !caps.Has(entity.LockFeatureOpen) {
+				return "", caps.Unsupported("open", "it can only lock and unlock")
+			}
+			return "open", nil
+		case "toggle":
+			if state.State == "locked" {
+				return "unlock", nil
+			}
+			return "lock", nil
+		}
+		return "", caps.Unsupported(action, "")
+	}
+
+	if action == "arm" {
+		action = "arm_away"
+		if value != "" {
+			action = "arm_" + value
+		}
+	}
+
+	var feature int
+	switch action {
+	case "disarm", "off":
+		return "alarm_disarm", nil
+	case "arm_home", "home":
+		action, feature = "arm_home", entity.AlarmFeatureArmHome
+	case "arm_away", "away":
+		action, feature = "arm_away", entity.AlarmFeatureArmAway
+	case "arm_night", "night":
+		action, feature = "arm_night", entity.AlarmFeatureArmNight
+	default:
+		return "", caps.Unsupported(action, "")
+	}
+
+	if !caps.Has(feature) {
+		return "", caps.Unsupported(action, "")
+	}
+	return "alarm_" + action, nil
+}
+
+// confirmDestructive asks before calling service on the entity when
+// preferences.confirm_destructive lists it for the entity's domain or device
+// class.
+func (c *Commander) confirmDestructive(state client.EntityState, service string, yes bool) error {
+	domain := strings.SplitN(state.EntityID, ".", 2)[0]
+	deviceClass, _ := state.Attributes["device_class"].(string)
+	if !c.config.Preferences.ConfirmDestructive.Requires(domain, deviceClass, service) {
+		return nil
+	}
+
+	action := strings.ReplaceAll(strings.TrimPrefix(service, "alarm_"), "_", " ")
+	if c.noPrompt && !yes {
+		return withExitCode(ExitUsage, fmt.Errorf("%s %s %w; commands in a parallel block cannot prompt, so pass --yes", action, state.EntityID, errNotConfirmed))
+	}
+	return confirmAction(fmt.Sprintf("%s %s", action, state.EntityID), yes)
+}
+
+// codeFormat reports whether service needs a code, and the code_format the
+// entity gives for it: a regular expression for locks, "number" or "text" for
+// alarm panels.
+func codeFormat(state client.EntityState, service string) (string, bool) {
+	format, _ := state.Attributes["code_format"].(string)
+	if format == "" {
+		return "", false
+	}
+
+	// Alarm panels can allow arming without a code.
+	if strings.HasPrefix(service, "alarm_arm_") {
+		if required, ok := state.Attributes["code_arm_required"].(bool); ok && !required {
+			return "", false
+		}
+	}
+	return format, true
+}
+
+// readCode prompts for a code without echo, or takes it from HASS_CODE when
+// there is no terminal or prompt is false.
+func readCode(entityID string, prompt bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !prompt || !term.IsTerminal(fd) {
+		if code := os.Getenv("HASS_CODE"); code != "" {
+			return code, nil
+		}
+		return "", withExitCode(ExitUsage, fmt.Errorf("%s needs a code; run from a terminal or set HASS_CODE", entityID))
+	}
+
+	fmt.Fprintf(os.Stderr, "Code for %s: ", entityID)
+	code, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read code: %w", err)
+	}
+	if len(code) == 0 {
+		return "", withExitCode(ExitUsage, fmt.Errorf("code must not be empty"))
+	}
+	return string(code), nil
+}
+
+// checkCode verifies a code against its format before it is sent.
+func checkCode(format, code string) error {
+	switch format {
+	case "number":
+		if strings.Trim(code, "0123456789") != "" {
+			return withExitCode(ExitUsage, fmt.Errorf("code must be a number"))
+		}
+	case "text":
+	default:
+		pattern, err := regexp.Compile("^(?:" + format + ")$")
+		if err == nil && !pattern.MatchString(code) {
+			return withExitCode(ExitUsage, fmt.Errorf("code does not match the required format"))
+		}
+	}
+	return nil
+}
+
+// auditSecurityAction appends a line for a lock or alarm action to the audit
+// log. A log that cannot be written only warns, as the action has already run.
+func auditSecurityAction(entityID, service string, actionErr error) {
+	result := "ok"
+	switch {
+	case errors.Is(actionErr, errNotConfirmed):
+		result = "not confirmed"
+	case actionErr != nil:
+		result = "failed: " + actionErr.Error()
+	}
+
+	username := "unknown"
+	if current, err := user.Current(); err == nil {
+		username = current.Username
+	}
+
+	line := fmt.Sprintf("%s user=%s entity=%s service=%s result=%q\n",
+		time.Now().Format(time.RFC3339), username, entityID, service, result)
+
+	if err := appendAuditLine(line); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+}
+
+func appendAuditLine(line string) error {
+	path, err := config.AuditLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func TestSecurityService(t *testing.T) {
+	lock := client.EntityState{
+		EntityID:   "lock.front_door",
+		State:      "locked",
+		Attributes: map[string]interface{}{"supported_features": float64(entity.LockFeatureOpen)},
+	}
+	alarm := client.EntityState{
+		EntityID:   "alarm_control_panel.house",
+		State:      "disarmed",
+		Attributes: map[string]interface{}{"supported_features": float64(entity.AlarmFeatureArmHome | entity.AlarmFeatureArmAway)},
+	}
+
+	tests := []struct {
+		state  client.EntityState
+		action string
+		value  string
+		want   string
+	}{
+		{lock, "unlock", "", "unlock"},
+		{lock, "open", "", "open"},
+		{lock, "toggle", "", "unlock"},
+		{alarm, "arm_home", "", "alarm_arm_home"},
+		{alarm, "arm", "", "alarm_arm_away"},
+		{alarm, "arm", "home", "alarm_arm_home"},
+		{alarm, "disarm", "", "alarm_disarm"},
+	}
+
+	for _, tt := range tests {
+		got, err := securityService(tt.state, tt.action, tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("securityService(%s, %q, %q) = %q (err=%v), expected %q", tt.state.EntityID, tt.action, tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, args := range [][2]string{{"arm_night", ""}, {"arm", "night"}, {"lock", ""}} {
+		if _, err := securityService(alarm, args[0], args[1]); !errors.Is(err, entity.ErrNotSupported) {
+			t.Errorf("%s %s: expected ErrNotSupported, got %v", args[0], args[1], err)
+		}
+	}
+}
+
+func TestCodeFormat(t *testing.T) {
+	alarm := client.EntityState{
+		EntityID:   "alarm_control_panel.house",
+		Attributes: map[string]interface{}{"code_format": "number", "code_arm_required": false},
+	}
+
+	if _, ok := codeFormat(alarm, "alarm_arm_away"); ok {
+		t.Error("expected arming without a code when code_arm_required is false")
+	}
+	if format, ok := codeFormat(alarm, "alarm_disarm"); !ok || format != "number" {
+		t.Errorf("expected disarming to need a number, got %q, %v", format, ok)
+	}
+	if _, ok := codeFormat(client.EntityState{EntityID: "lock.shed"}, "unlock"); ok {
+		t.Error("expected no code for a lock without code_format")
+	}
+
+	if err := checkCode("number", "12a4"); err == nil {
+		t.Error("expected a non-numeric code to be rejected")
+	}
+	if err := checkCode(`\d{4}`, "1234"); err != nil {
+		t.Errorf("expected the code to match, got %v", err)
+	}
+	if err := checkCode(`\d{4}`, "123"); err == nil {
+		t.Error("expected a short code to be rejected")
+	}
+}
+
+func TestUnlockNeedsConfirmationAndIsAudited(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	commander := newTestCommander(t, client.EntityState{
+		EntityID: "lock.front_door",
+		State:    "locked",
+		Attributes: map[string]interface{}{
+			"friendly_name":      "Front Door Lock",
+			"supported_features": 0,
+		},
+	})
+
+	err := commander.Execute([]string{"front", "lock", "toggle"})
+	if !errors.Is(err, errNotConfirmed) {
+		t.Fatalf("expected toggling a locked door to need confirmation, got %v", err)
+	}
+
+	commander.config.Preferences.ConfirmDestructive = nil
+	_ = commander.Execute([]string{"front", "lock", "unlock"})
+
+	data, err := os.ReadFile(filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "hass", "audit.log"))
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit lines, got %q", lines)
+	}
+	if !strings.Contains(lines[0], "entity=lock.front_door service=unlock result=\"not confirmed\"") {
+		t.Errorf("unexpected audit line: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "service=unlock result=\"ok\"") {
+		t.Errorf("expected the call to be audited, got %s", lines[1])
+	}
+}
+
+func TestDoorUnlockResolvesToLock(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	commander, fake := newRecordingCommander(t,
+		client.EntityState{
+			EntityID:   "cover.front_door",
+			State:      "closed",
+			Attributes: map[string]interface{}{"friendly_name": "Front Door Opener Motor"},
+		},
+		client.EntityState{
+			EntityID:   "lock.front_door",
+			State:      "locked",
+			Attributes: map[string]interface{}{"friendly_name": "Front Door"},
+		},
+	)
+
+	if err := commander.Execute([]string{"front", "door", "unlock", "--yes"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if call := fake.lastCall(t); call.Domain != "lock" || call.Service != "unlock" || call.Data["entity_id"] != "lock.front_door" {
+		t.Errorf("expected lock.unlock on lock.front_door, got %s.%s %v", call.Domain, call.Service, call.Data)
+	}
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"golang.org/x/term"
+)
+
+const shellPrompt = "hass> "
+
+func (c *Commander) handleShellCommand(args []string) error {
+	if len(args) > 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("usage: shell"))
+	}
+
+	// Keep one entity list warm for the whole session; "refresh" reloads it.
+	c.reuseStates()
+	go c.warmStates()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.watchConfig(ctx)
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return c.runShellReader(os.Stdin)
+	}
+
+	return c.runShellTerminal(fd)
+}
+
+func (c *Commander) warmStates() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.HomeAssistant.Timeout)
+	defer cancel()
+
+	_, _ = c.resolver.States(ctx)
+}
+
+func (c *Commander) runShellReader(input io.Reader) error {
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		if c.runShellLine(scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func (c *Commander) runShellTerminal(fd int) error {
+	rawState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to initialize terminal: %w", err)
+	}
+	defer func() { _ = term.Restore(fd, rawState) }()
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, shellPrompt)
+	terminal.AutoCompleteCallback = c.shellAutoComplete(terminal)
+
+	if width, height, err := term.GetSize(fd); err == nil {
+		_ = terminal.SetSize(width, height)
+	}
+
+	fmt.Fprintln(terminal, "Home Assistant shell. Type 'help' for commands, 'refresh' to reload entities, 'exit' to quit.")
+
+	for {
+		line, err := terminal.ReadLine()
+		if err == io.EOF {
+			fmt.Fprintln(terminal)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		// Commands print with plain newlines, so run them in cooked mode.
+		_ = term.Restore(fd, rawState)
+		exit := c.runShellLine(line)
+		if exit {
+			return nil
+		}
+		if rawState, err = term.MakeRaw(fd); err != nil {
+			return fmt.Errorf("failed to initialize terminal: %w", err)
+		}
+	}
+}
+
+// runShellLine executes one line of input and reports whether the shell
+// should exit.
+func (c *Commander) runShellLine(line string) bool {
+	c.applyConfigReload()
+
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return false
+	}
+
+	args, err := splitCommandLine(line)
+	if err != nil {
+		fmt.Fprintf(c.out, "✗ %v\n", err)
+		return false
+	}
+
+	switch args[0] {
+	case "exit", "quit":
+		return true
+	case "refresh":
+		c.invalidateStates()
+		c.warmStates()
+		fmt.Fprintln(c.out, "✓ Entities reloaded")
+		return false
+	case "shell", "tui":
+		fmt.Fprintf(c.out, "✗ %s cannot be started from the shell\n", args[0])
+		return false
+	}
+
+	if err := c.Execute(args); err != nil {
+		fmt.Fprintf(c.out, "✗ %v\n", Explain(err))
+	}
+	return false
+}
+
+func (c *Commander) shellAutoComplete(terminal *term.Terminal) func(string, int, rune) (string, int, bool) {
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		before := line[:pos]
+		wordStart := strings.LastIndexAny(before, " \t") + 1
+		prefix := before[wordStart:]
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.config.HomeAssistant.Timeout)
+		defer cancel()
+
+		candidates := c.completions(strings.Fields(before[:wordStart]), prefix, func() []client.EntityState {
+			states, err := c.resolver.States(ctx)
+			if err != nil {
+				return nil
+			}
+			return states
+		})
+		if len(candidates) == 0 {
+			return "", 0, false
+		}
+
+		completion := commonPrefix(candidates)
+		if len(candidates) == 1 {
+			completion += " "
+		}
+
+		if len(completion) <= len(prefix) {
+			fmt.Fprintln(terminal, strings.Join(candidates, "  "))
+			return "", 0, false
+		}
+
+		newLine := before[:wordStart] + completion + line[pos:]
+		return newLine, wordStart + len(completion), true
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+func TestCompletions(t *testing.T) {
+	commander := newTestCommander(t,
+		client.EntityState{
+			EntityID:   "light.living_room_lamp",
+			Attributes: map[string]interface{}{"friendly_name": "Living Room Lamp"},
+		},
+		client.EntityState{
+			EntityID:   "scene.movie_time",
+			Attributes: map[string]interface{}{"friendly_name": "Movie Time"},
+		},
+	)
+	commander.config.Aliases["lr"] = "living room"
+	commander.reuseStates()
+
+	states := func() []client.EntityState {
+		states, _ := commander.resolver.States(context.Background())
+		return states
+	}
+
+	tests := []struct {
+		args     []string
+		prefix   string
+		expected []string
+	}{
+		{nil, "liv", []string{"living"}},
+		{nil, "l", []string{"lamp", "lamps", "light", "lights", "living", "lock", "locks", "lr"}},
+		{nil, "sh", []string{"shade", "shades", "shell"}},
+		{[]string{"config"}, "", configSubcommands},
+		{[]string{"scene"}, "mo", []string{"movie"}},
+		{[]string{"living"}, "lig", []string{"light", "lights"}},
+		{[]string{"living", "lights"}, "of", []string{"off"}},
+		{[]string{"version"}, "", nil},
+	}
+
+	for _, test := range tests {
+		result := commander.completions(test.args, test.prefix, states)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("completions(%q, %q) = %q, expected %q", test.args, test.prefix, result, test.expected)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	if got := commonPrefix([]string{"light", "lights"}); got != "light" {
+		t.Errorf("expected light, got %q", got)
+	}
+	if got := commonPrefix([]string{"living", "lr"}); got != "l" {
+		t.Errorf("expected l, got %q", got)
+	}
+}
+
+func TestRunShellReader(t *testing.T) {
+	commander := newTestCommander(t)
+
+	input := strings.NewReader("# comment\n\nhelp\nexit\nbogus command\n")
+	if err := commander.runShellReader(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !commander.runShellLine("quit") {
+		t.Error("expected quit to end the shell")
+	}
+	if commander.runShellLine("shell") {
+		t.Error("expected nested shell to be refused without exiting")
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/config"
+	"golang.org/x/term"
+)
+
+const tokenUsage = "usage: config token set|clear|migrate [--store keyring|file]"
+
+// NeedsToken reports whether args run a command that may talk to Home
+// Assistant. Help, version, completion and config token never do, so they
+// work without reading the token store.
+func NeedsToken(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "__complete", "completion", "help", "--help", "-h", "version", "--version", "-v":
+		return false
+	case "config":
+		return len(args) < 2 || args[1] != "token"
+	}
+	return true
+}
+
+func (c *Commander) handleTokenCommand(args []string) error {
+	positional, flags, err := splitFlags(args, "store")
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if len(positional) != 1 {
+		return withExitCode(ExitUsage, fmt.Errorf(tokenUsage))
+	}
+
+	storeName, ok := flags.get("store")
+	if !ok {
+		storeName = config.TokenStoreKeyring
+	}
+
+	switch positional[0] {
+	case "set":
+		token, err := readToken()
+		if err != nil {
+			return err
+		}
+		return c.moveToken(storeName, token, "stored")
+	case "migrate":
+		if c.config.HomeAssistant.Token == "" || c.config.TokenFromStore() {
+			return fmt.Errorf("config.yaml has no plaintext token to migrate")
+		}
+		return c.moveToken(storeName, c.config.HomeAssistant.Token, "moved from config.yaml")
+	case "clear":
+		return c.clearToken()
+	default:
+		return withExitCode(ExitUsage, fmt.Errorf(tokenUsage))
+	}
+}
+
+func (c *Commander) moveToken(storeName, token, verb string) error {
+	store, err := c.config.StoreToken(storeName, token)
+	if err != nil {
+		return err
+	}
+
+	if err := c.config.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "✓ Token %s to %s\n", verb, store.Name())
+	return nil
+}
+
+func (c *Commander) clearToken() error {
+	name := c.config.TokenStoreName()
+	if name != config.TokenStoreConfig {
+		store, err := c.config.NewTokenStore(name)
+		if err != nil {
+			return err
+		}
+		if err := store.Delete(); err != nil {
+			return fmt.Errorf("failed to clear token from %s: %w", store.Name(), err)
+		}
+	}
+
+	c.config.HomeAssistant.Token = ""
+	if err := c.config.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.out, "✓ Token cleared")
+	return nil
+}
+
+// readToken prompts for the token without echo on a terminal, or reads the
+// first line of stdin otherwise.
+func readToken() (string, error) {
+	var token string
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Access token: ")
+		secret, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+		token = string(secret)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+		token = line
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", withExitCode(ExitUsage, fmt.Errorf("token must not be empty"))
+	}
+	return token, nil
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func (c *Commander) handleVacuumWithValue(ctx context.Context, caps entity.Capabilities, action, value string) error {
+	service, serviceData, err := vacuumServiceData(caps, strings.ToLower(action), value)
+	if err != nil {
+		return err
+	}
+
+	target := map[string]interface{}{
+		"entity_id": caps.EntityID,
+	}
+
+	_, err = c.client.CallService(ctx, "vacuum", service, target, serviceData)
+	return err
+}
+
+// vacuumServiceData maps an action and optional value to a vacuum service
+// call:
+//
+//	start, on, clean             start cleaning
+//	pause, stop
+//	dock, return, home, off      return to the dock
+//	locate, spot                 play the locate sound, clean a spot
+//	fan_speed max, suction max   fan speed from the vacuum's list
+//	segment 16,17, clean 16 17   clean map segments (rooms) by ID
+//
+// Home Assistant has no service for segment cleaning, so segments are sent
+// as the app_segment_clean command that only Xiaomi and Roborock firmware
+// understands. Other vacuums get a NotSupportedError rather than a command
+// they would ignore.
+func vacuumServiceData(caps entity.Capabilities, action, value string) (string, map[string]interface{}, error) {
+	none := map[string]interface{}{}
+
+	if action == "clean" && value != "" {
+		action = "segment"
+	}
+
+	var service string
+	var feature int
+	switch action {
+	case "start", "on", "clean":
+		service, feature = "start", entity.VacuumFeatureStart
+	case "pause":
+		service, feature = "pause", entity.VacuumFeaturePause
+	case "stop":
+		service, feature = "stop", entity.VacuumFeatureStop
+	case "dock", "return", "return_to_base", "home", "off":
+		service, feature = "return_to_base", entity.VacuumFeatureReturnHome
+	case "locate", "find":
+		service, feature = "locate", entity.VacuumFeatureLocate
+	case "spot", "clean_spot":
+		service, feature = "clean_spot", entity.VacuumFeatureCleanSpot
+
+	case "fan_speed", "fan", "suction":
+		if !caps.Has(entity.VacuumFeatureFanSpeed) {
+			return "", nil, caps.Unsupported("fan_speed", "it has no fan speeds")
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		speed, err := caps.MatchOption("fan_speed", value, caps.FanSpeeds)
+		if err != nil {
+			return "", nil, err
+		}
+		return "set_fan_speed", map[string]interface{}{"fan_speed": speed}, nil
+
+	case "segment", "segments", "clean_segment", "room", "rooms":
+		if !caps.Has(entity.VacuumFeatureSendCommand) {
+			return "", nil, caps.Unsupported("segment", "it does not accept commands")
+		}
+		if !caps.SegmentCleaning {
+			return "", nil, caps.Unsupported("segment", "segment cleaning only works on Xiaomi and Roborock vacuums")
+		}
+		segments, err := parseSegments(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return "send_command", map[string]interface{}{
+			"command": "app_segment_clean",
+			"params":  segments,
+		}, nil
+
+	default:
+		return "", nil, caps.Unsupported(action, "")
+	}
+
+	if !caps.Has(feature) {
+		return "", nil, caps.Unsupported(action, "")
+	}
+	return service, none, nil
+}
+
+// parseSegments reads segment IDs separated by commas or spaces.
+func parseSegments(value string) ([]int, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("segment needs one or more segment IDs, as in 16,17")
+	}
+
+	segments := make([]int, 0, len(fields))
+	for _, field := range fields {
+		id, err := strconv.Atoi(field)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid segment ID: %s", field)
+		}
+		segments = append(segments, id)
+	}
+	return segments, nil
+}
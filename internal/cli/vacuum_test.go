@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func vacuumCapabilities(features int) entity.Capabilities {
+	return entity.CapabilitiesOf(client.EntityState{
+		EntityID: "vacuum.roborock",
+		Attributes: map[string]interface{}{
+			"supported_features": float64(features),
+			"fan_speed_list":     []interface{}{"Silent", "Standard", "Turbo", "Max"},
+		},
+	})
+}
+
+func TestVacuumServiceData(t *testing.T) {
+	robot := vacuumCapabilities(entity.VacuumFeatureStart | entity.VacuumFeaturePause | entity.VacuumFeatureReturnHome |
+		entity.VacuumFeatureLocate | entity.VacuumFeatureFanSpeed | entity.VacuumFeatureSendCommand)
+
+	tests := []struct {
+		action      string
+		value       string
+		wantService string
+		want        map[string]interface{}
+	}{
+		{"start", "", "start", map[string]interface{}{}},
+		{"on", "", "start", map[string]interface{}{}},
+		{"pause", "", "pause", map[string]interface{}{}},
+		{"dock", "", "return_to_base", map[string]interface{}{}},
+		{"off", "", "return_to_base", map[string]interface{}{}},
+		{"locate", "", "locate", map[string]interface{}{}},
+		{"fan_speed", "turbo", "set_fan_speed", map[string]interface{}{"fan_speed": "Turbo"}},
+		{"suction", "max", "set_fan_speed", map[string]interface{}{"fan_speed": "Max"}},
+		{"segment", "16,17", "send_command", map[string]interface{}{"command": "app_segment_clean", "params": []int{16, 17}}},
+		{"clean", "18 19", "send_command", map[string]interface{}{"command": "app_segment_clean", "params": []int{18, 19}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" "+tt.value, func(t *testing.T) {
+			service, got, err := vacuumServiceData(robot, tt.action, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service != tt.wantService {
+				t.Errorf("expected vacuum.%s, got vacuum.%s", tt.wantService, service)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVacuumServiceDataErrors(t *testing.T) {
+	basic := vacuumCapabilities(entity.VacuumFeatureStart | entity.VacuumFeatureReturnHome)
+	for _, args := range [][2]string{{"pause", ""}, {"locate", ""}, {"fan_speed", "max"}, {"segment", "16"}} {
+		if _, _, err := vacuumServiceData(basic, args[0], args[1]); !errors.Is(err, entity.ErrNotSupported) {
+			t.Errorf("%s %s: expected ErrNotSupported, got %v", args[0], args[1], err)
+		}
+	}
+
+	// Segment cleaning is a Xiaomi and Roborock command, so other vacuums
+	// refuse it even when they accept commands.
+	other := entity.CapabilitiesOf(client.EntityState{
+		EntityID: "vacuum.upstairs",
+		Attributes: map[string]interface{}{
+			"friendly_name":      "Upstairs Vacuum",
+			"supported_features": float64(entity.VacuumFeatureSendCommand),
+		},
+	})
+	if _, _, err := vacuumServiceData(other, "segment", "16"); !errors.Is(err, entity.ErrNotSupported) || !strings.Contains(err.Error(), "Roborock") {
+		t.Errorf("expected segment cleaning to be refused on other vacuums, got %v", err)
+	}
+
+	full := vacuumCapabilities(entity.VacuumFeatureFanSpeed | entity.VacuumFeatureSendCommand)
+	for _, args := range [][2]string{{"fan_speed", "ludicrous"}, {"segment", "kitchen"}, {"segment", ""}} {
+		if _, _, err := vacuumServiceData(full, args[0], args[1]); err == nil {
+			t.Errorf("%s %s: expected an error", args[0], args[1])
+		}
+	}
+}
+
+func TestApplianceRouting(t *testing.T) {
+	states := []client.EntityState{
+		{
+			EntityID: "vacuum.roborock",
+			State:    "docked",
+			Attributes: map[string]interface{}{
+				"friendly_name":      "Hallway Roborock",
+				"supported_features": float64(entity.VacuumFeatureStart | entity.VacuumFeatureReturnHome | entity.VacuumFeatureFanSpeed | entity.VacuumFeatureSendCommand),
+				"fan_speed_list":     []interface{}{"Silent", "Turbo"},
+			},
+		},
+		{
+			EntityID: "lawn_mower.automower",
+			State:    "mowing",
+			Attributes: map[string]interface{}{
+				"friendly_name":      "Garden Automower",
+				"supported_features": float64(entity.LawnMowerFeatureStartMowing | entity.LawnMowerFeatureDock),
+			},
+		},
+		{
+			EntityID: "water_heater.boiler",
+			State:    "eco",
+			Attributes: map[string]interface{}{
+				"friendly_name":      "Basement Boiler",
+				"supported_features": float64(entity.WaterHeaterFeatureTargetTemperature | entity.WaterHeaterFeatureOperationMode),
+				"operation_list":     []interface{}{"eco", "electric"},
+				"min_temp":           float64(43),
+				"max_temp":           float64(60),
+			},
+		},
+	}
+
+	testRouting(t, states, []routingCase{
+		{[]string{"hallway", "vacuum", "start"}, "vacuum", "start",
+			map[string]interface{}{"entity_id": "vacuum.roborock"}},
+		{[]string{"hallway", "vacuum", "off"}, "vacuum", "return_to_base",
+			map[string]interface{}{"entity_id": "vacuum.roborock"}},
+		{[]string{"hallway", "vacuum", "suction", "turbo"}, "vacuum", "set_fan_speed",
+			map[string]interface{}{"entity_id": "vacuum.roborock", "fan_speed": "Turbo"}},
+		{[]string{"hallway", "vacuum", "segment", "16,17"}, "vacuum", "send_command",
+			map[string]interface{}{"entity_id": "vacuum.roborock", "command": "app_segment_clean", "params": []interface{}{16.0, 17.0}}},
+		{[]string{"garden", "mower", "dock"}, "lawn_mower", "dock",
+			map[string]interface{}{"entity_id": "lawn_mower.automower"}},
+		{[]string{"basement", "heater", "temperature", "50"}, "water_heater", "set_temperature",
+			map[string]interface{}{"entity_id": "water_heater.boiler", "temperature": 50.0}},
+		{[]string{"basement", "heater", "electric"}, "water_heater", "set_operation_mode",
+			map[string]interface{}{"entity_id": "water_heater.boiler", "operation_mode": "electric"}},
+	})
+}
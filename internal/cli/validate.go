@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/quinncuatro/hass-cli/internal/config"
+)
+
+func (c *Commander) handleValidateCommand(args []string) error {
+	if len(args) > 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("usage: config validate [file]"))
+	}
+
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		defaultPath, err := config.Path()
+		if err != nil {
+			return fmt.Errorf("failed to get config path: %w", err)
+		}
+		path = defaultPath
+	}
+
+	_, problems, err := config.ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range problems {
+		if problem.Warning {
+			fmt.Fprintf(c.out, "⚠ %s\n", problem)
+		} else {
+			fmt.Fprintf(c.out, "✗ %s\n", problem)
+		}
+	}
+
+	if config.HasErrors(problems) {
+		return withExitCode(ExitConfig, fmt.Errorf("%s is invalid", path))
+	}
+
+	fmt.Fprintf(c.out, "✓ %s is valid\n", path)
+	return nil
+}
+
+// PrintConfigWarnings reports non-fatal config problems on stderr.
+func PrintConfigWarnings(cfg *config.Config) {
+	if cfg.Output.Verbosity == 0 {
+		return
+	}
+
+	for _, problem := range cfg.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: config: %s\n", problem)
+	}
+}
+
+// InspectsConfig reports whether args run a command that checks or upgrades
+// config.yaml itself, which must work even when the file does not load and
+// must see it before Load migrates it.
+func InspectsConfig(args []string) bool {
+	return len(args) >= 2 && args[0] == "config" && (args[1] == "validate" || args[1] == "migrate")
+}
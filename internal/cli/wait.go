@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+const defaultWaitInterval = time.Second
+
+type comparison struct {
+	op    string
+	value string
+}
+
+type attributeCondition struct {
+	name string
+	comparison
+}
+
+type waitCondition struct {
+	state *comparison
+	attrs []attributeCondition
+}
+
+var comparisonOperators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+func (c *Commander) handleWaitCommand(args []string) error {
+	positional, flags, err := splitFlags(args, "state", "attr", "timeout", "for", "interval")
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	if len(positional) == 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("usage: wait <query> --state <state> [--attr <expr>] [--for <duration>] [--timeout <duration>]"))
+	}
+
+	condition, err := parseWaitCondition(flags)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	timeout, err := durationFlag(flags, "timeout", 0)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	stableFor, err := durationFlag(flags, "for", 0)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	interval, err := durationFlag(flags, "interval", defaultWaitInterval)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if interval <= 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("--interval must be positive"))
+	}
+
+	query := strings.Join(positional, " ")
+
+	resolveCtx, cancelResolve := context.WithTimeout(context.Background(), c.config.HomeAssistant.Timeout)
+	match, err := c.resolver.ResolveEntity(resolveCtx, "", "", query)
+	cancelResolve()
+	if err != nil {
+		if errors.Is(err, entity.ErrEntityNotFound) {
+			return withExitCode(ExitNotFound, fmt.Errorf("no entity found matching %q", query))
+		}
+		return fmt.Errorf("failed to resolve entity: %w", err)
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if c.config.Output.Verbosity > 0 {
+		fmt.Fprintf(c.out, "⏳ Waiting for %s (%s) %s\n", match.FriendlyName, match.EntityID, condition)
+	}
+
+	state, err := c.waitForCondition(ctx, match.EntityID, condition, stableFor, interval)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return withExitCode(ExitTimeout, fmt.Errorf("timed out after %s waiting for %s %s", timeout, match.EntityID, condition))
+		}
+		return err
+	}
+
+	if c.config.Output.Verbosity > 0 {
+		fmt.Fprintf(c.out, "✓ %s is %s\n", match.FriendlyName, state.State)
+	}
+
+	return nil
+}
+
+func (c *Commander) waitForCondition(ctx context.Context, entityID string, condition waitCondition, stableFor, interval time.Duration) (*client.EntityState, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var heldSince time.Time
+	var lastErr error
+
+	for {
+		requestCtx, cancel := context.WithTimeout(ctx, c.config.HomeAssistant.Timeout)
+		state, err := client.Uncached(c.client).GetState(requestCtx, entityID)
+		cancel()
+
+		switch {
+		case err != nil:
+			// Keep polling through transient failures; report the last one
+			// if the wait eventually times out.
+			lastErr = err
+			heldSince = time.Time{}
+		case condition.matches(state):
+			if heldSince.IsZero() {
+				// Nothing about the entity has changed since last_updated,
+				// so the condition has held at least that long.
+				heldSince = time.Now()
+				if !state.LastUpdated.IsZero() && state.LastUpdated.Before(heldSince) {
+					heldSince = state.LastUpdated
+				}
+			}
+			if time.Since(heldSince) >= stableFor {
+				return state, nil
+			}
+		default:
+			heldSince = time.Time{}
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil && c.config.Output.Verbosity > 1 {
+				fmt.Fprintf(c.out, "Last error while polling %s: %v\n", entityID, lastErr)
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func parseWaitCondition(flags flagValues) (waitCondition, error) {
+	var condition waitCondition
+
+	if value, ok := flags.get("state"); ok {
+		cmp := parseComparison(value)
+		condition.state = &cmp
+	}
+
+	for _, expr := range flags["attr"] {
+		attr, err := parseAttributeCondition(expr)
+		if err != nil {
+			return condition, err
+		}
+		condition.attrs = append(condition.attrs, attr)
+	}
+
+	if condition.state == nil && len(condition.attrs) == 0 {
+		return condition, fmt.Errorf("wait requires --state or --attr")
+	}
+
+	return condition, nil
+}
+
+// parseComparison reads an optional leading operator from a value such as
+// ">=20"; a value without an operator is an equality check.
+func parseComparison(expr string) comparison {
+	expr = strings.TrimSpace(expr)
+	for _, op := range comparisonOperators {
+		if strings.HasPrefix(expr, op) {
+			return comparison{op: normalizeOperator(op), value: strings.TrimSpace(expr[len(op):])}
+		}
+	}
+	return comparison{op: "==", value: expr}
+}
+
+func parseAttributeCondition(expr string) (attributeCondition, error) {
+	idx := strings.IndexAny(expr, "<>=!")
+	if idx <= 0 {
+		return attributeCondition{}, fmt.Errorf("invalid attribute condition %q (expected e.g. brightness>200)", expr)
+	}
+
+	name := strings.TrimSpace(expr[:idx])
+	cmp := parseComparison(expr[idx:])
+	if cmp.value == "" {
+		return attributeCondition{}, fmt.Errorf("invalid attribute condition %q: missing value", expr)
+	}
+
+	return attributeCondition{name: name, comparison: cmp}, nil
+}
+
+func normalizeOperator(op string) string {
+	if op == "=" {
+		return "=="
+	}
+	return op
+}
+
+func (w waitCondition) matches(state *client.EntityState) bool {
+	if w.state != nil && !w.state.matches(state.State) {
+		return false
+	}
+
+	for _, attr := range w.attrs {
+		value, ok := state.Attributes[attr.name]
+		if !ok || value == nil {
+			return false
+		}
+		if !attr.matches(fmt.Sprint(value)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (w waitCondition) String() string {
+	var parts []string
+	if w.state != nil {
+		parts = append(parts, "state "+w.state.String())
+	}
+	for _, attr := range w.attrs {
+		parts = append(parts, attr.name+" "+attr.comparison.String())
+	}
+	return "until " + strings.Join(parts, " and ")
+}
+
+func (c comparison) matches(actual string) bool {
+	actualNum, actualErr := strconv.ParseFloat(actual, 64)
+	expectedNum, expectedErr := strconv.ParseFloat(c.value, 64)
+	numeric := actualErr == nil && expectedErr == nil
+
+	switch c.op {
+	case "==":
+		if numeric {
+			return actualNum == expectedNum
+		}
+		return strings.EqualFold(actual, c.value)
+	case "!=":
+		if numeric {
+			return actualNum != expectedNum
+		}
+		return !strings.EqualFold(actual, c.value)
+	case ">":
+		return numeric && actualNum > expectedNum
+	case ">=":
+		return numeric && actualNum >= expectedNum
+	case "<":
+		return numeric && actualNum < expectedNum
+	case "<=":
+		return numeric && actualNum <= expectedNum
+	default:
+		return false
+	}
+}
+
+func (c comparison) String() string {
+	if c.op == "==" {
+		return "= " + c.value
+	}
+	return c.op + " " + c.value
+}
+
+func durationFlag(flags flagValues, name string, fallback time.Duration) (time.Duration, error) {
+	value, ok := flags.get(name)
+	if !ok {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s value %q: %w", name, value, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("--%s must not be negative", name)
+	}
+	return duration, nil
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+func TestSplitFlags(t *testing.T) {
+	positional, flags, err := splitFlags(
+		[]string{"front", "door", "--state", "open", "--attr=brightness>200", "--attr", "volume<0.5", "--verbose", "-5"},
+		"state", "attr",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(positional, " ") != "front door -5" {
+		t.Errorf("unexpected positional arguments: %v", positional)
+	}
+
+	if state, _ := flags.get("state"); state != "open" {
+		t.Errorf("expected state open, got %q", state)
+	}
+
+	if len(flags["attr"]) != 2 {
+		t.Errorf("expected 2 attr flags, got %v", flags["attr"])
+	}
+
+	if !flags.has("verbose") {
+		t.Error("expected boolean flag verbose to be set")
+	}
+
+	if _, _, err := splitFlags([]string{"--state"}, "state"); err == nil {
+		t.Error("expected error for missing flag value")
+	}
+}
+
+func TestComparisonMatches(t *testing.T) {
+	tests := []struct {
+		expr     string
+		actual   string
+		expected bool
+	}{
+		{"open", "open", true},
+		{"open", "OPEN", true},
+		{"open", "closed", false},
+		{">200", "255", true},
+		{">200", "200", false},
+		{">=200", "200", true},
+		{"<20.5", "19", true},
+		{"!=off", "on", true},
+		{"=100", "100.0", true},
+		{">10", "unavailable", false},
+	}
+
+	for _, test := range tests {
+		cmp := parseComparison(test.expr)
+		if result := cmp.matches(test.actual); result != test.expected {
+			t.Errorf("comparison %q against %q = %t, expected %t", test.expr, test.actual, result, test.expected)
+		}
+	}
+}
+
+func TestParseAttributeCondition(t *testing.T) {
+	attr, err := parseAttributeCondition("brightness>=200")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attr.name != "brightness" || attr.op != ">=" || attr.value != "200" {
+		t.Errorf("unexpected condition: %+v", attr)
+	}
+
+	for _, invalid := range []string{"brightness", ">200", "brightness>"} {
+		if _, err := parseAttributeCondition(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestWaitConditionMatches(t *testing.T) {
+	condition, err := parseWaitCondition(flagValues{
+		"state": {"on"},
+		"attr":  {"brightness>200"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state := &client.EntityState{
+		State:      "on",
+		Attributes: map[string]interface{}{"brightness": float64(255)},
+	}
+	if !condition.matches(state) {
+		t.Error("expected condition to match")
+	}
+
+	state.Attributes["brightness"] = float64(100)
+	if condition.matches(state) {
+		t.Error("expected condition not to match with low brightness")
+	}
+
+	delete(state.Attributes, "brightness")
+	if condition.matches(state) {
+		t.Error("expected condition not to match without the attribute")
+	}
+
+	if _, err := parseWaitCondition(flagValues{}); err == nil {
+		t.Error("expected error when no condition is given")
+	}
+}
+
+func TestHandleWaitCommand(t *testing.T) {
+	door := client.EntityState{
+		EntityID:    "cover.garage_door",
+		State:       "closed",
+		Attributes:  map[string]interface{}{"friendly_name": "Garage Door"},
+		LastUpdated: time.Now().Add(-time.Hour),
+	}
+	commander := newTestCommander(t, door)
+
+	err := commander.Execute([]string{"wait", "garage", "door", "--state", "closed", "--for", "10m", "--timeout", "1s"})
+	if err != nil {
+		t.Fatalf("expected condition to hold, got %v", err)
+	}
+
+	err = commander.Execute([]string{"wait", "garage", "door", "--state", "open", "--timeout", "50ms", "--interval", "10ms"})
+	if code := ExitCode(err); code != ExitTimeout {
+		t.Errorf("expected exit code %d for timeout, got %d (%v)", ExitTimeout, code, err)
+	}
+
+	err = commander.Execute([]string{"wait", "refrigerator", "--state", "open", "--timeout", "50ms"})
+	if code := ExitCode(err); code != ExitNotFound {
+		t.Errorf("expected exit code %d for unknown entity, got %d (%v)", ExitNotFound, code, err)
+	}
+
+	err = commander.Execute([]string{"wait", "garage", "door"})
+	if code := ExitCode(err); code != ExitUsage {
+		t.Errorf("expected exit code %d without a condition, got %d (%v)", ExitUsage, code, err)
+	}
+}
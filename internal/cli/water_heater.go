@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func (c *Commander) handleWaterHeaterWithValue(ctx context.Context, caps entity.Capabilities, action, value string) error {
+	// As for climate, the unit system is only needed to convert °C or °F.
+	var unit string
+	if temperatureUnitPattern.MatchString(value) {
+		status, err := c.client.GetSystemStatus(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get unit system: %w", err)
+		}
+		unit = status.UnitSystem.Temperature
+	}
+
+	service, serviceData, err := waterHeaterServiceData(caps, strings.ToLower(action), value, unit)
+	if err != nil {
+		return err
+	}
+
+	target := map[string]interface{}{
+		"entity_id": caps.EntityID,
+	}
+
+	_, err = c.client.CallService(ctx, "water_heater", service, target, serviceData)
+	return err
+}
+
+// waterHeaterServiceData maps an action and optional value to a
+// water_heater service call:
+//
+//	temperature 50, temp 120F    target temperature
+//	mode eco, eco                operation mode from the heater's list
+//	away [on|off]                away mode
+func waterHeaterServiceData(caps entity.Capabilities, action, value, unit string) (string, map[string]interface{}, error) {
+	switch action {
+	case "temp", "temperature", "target":
+		if !caps.Has(entity.WaterHeaterFeatureTargetTemperature) {
+			return "", nil, caps.Unsupported("temperature", "it has no target temperature")
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		temp, err := parseTemperature(caps, value, unit)
+		if err != nil {
+			return "", nil, err
+		}
+		return "set_temperature", map[string]interface{}{"temperature": temp}, nil
+
+	case "mode", "operation", "operation_mode":
+		if !caps.Has(entity.WaterHeaterFeatureOperationMode) {
+			return "", nil, caps.Unsupported("mode", "it has no operation modes")
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("%s needs a value", action)
+		}
+		mode, err := caps.MatchOption("mode", value, caps.OperationModes)
+		if err != nil {
+			return "", nil, err
+		}
+		return "set_operation_mode", map[string]interface{}{"operation_mode": mode}, nil
+
+	case "away", "away_mode":
+		if !caps.Has(entity.WaterHeaterFeatureAwayMode) {
+			return "", nil, caps.Unsupported("away", "it has no away mode")
+		}
+		away := true
+		if value != "" {
+			var err error
+			if away, err = parseOnOff(value); err != nil {
+				return "", nil, fmt.Errorf("invalid away value: %s (use on or off)", value)
+			}
+		}
+		return "set_away_mode", map[string]interface{}{"away_mode": away}, nil
+	}
+
+	// An operation mode can be named directly, as in "hass water heater eco".
+	if value == "" && caps.Has(entity.WaterHeaterFeatureOperationMode) && len(caps.OperationModes) > 0 {
+		if mode, err := caps.MatchOption("mode", action, caps.OperationModes); err == nil {
+			return "set_operation_mode", map[string]interface{}{"operation_mode": mode}, nil
+		}
+	}
+
+	return "", nil, caps.Unsupported(action, "")
+}
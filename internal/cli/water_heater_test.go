@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+	"github.com/quinncuatro/hass-cli/internal/entity"
+)
+
+func TestWaterHeaterServiceData(t *testing.T) {
+	heater := entity.CapabilitiesOf(client.EntityState{
+		EntityID: "water_heater.boiler",
+		Attributes: map[string]interface{}{
+			"supported_features": float64(entity.WaterHeaterFeatureTargetTemperature | entity.WaterHeaterFeatureOperationMode | entity.WaterHeaterFeatureAwayMode),
+			"operation_list":     []interface{}{"eco", "electric", "heat_pump", "off"},
+			"min_temp":           float64(43),
+			"max_temp":           float64(60),
+		},
+	})
+
+	tests := []struct {
+		action      string
+		value       string
+		unit        string
+		wantService string
+		want        map[string]interface{}
+	}{
+		{"temperature", "50", "", "set_temperature", map[string]interface{}{"temperature": 50.0}},
+		{"temp", "122F", "°C", "set_temperature", map[string]interface{}{"temperature": 50.0}},
+		{"mode", "heat pump", "", "set_operation_mode", map[string]interface{}{"operation_mode": "heat_pump"}},
+		{"eco", "", "", "set_operation_mode", map[string]interface{}{"operation_mode": "eco"}},
+		{"away", "", "", "set_away_mode", map[string]interface{}{"away_mode": true}},
+		{"away", "off", "", "set_away_mode", map[string]interface{}{"away_mode": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+" "+tt.value, func(t *testing.T) {
+			service, got, err := waterHeaterServiceData(heater, tt.action, tt.value, tt.unit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service != tt.wantService {
+				t.Errorf("expected water_heater.%s, got water_heater.%s", tt.wantService, service)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, _, err := waterHeaterServiceData(heater, "temperature", "80", ""); !errors.Is(err, entity.ErrNotSupported) {
+		t.Errorf("expected 80 to be outside the heater's range, got %v", err)
+	}
+	if _, _, err := waterHeaterServiceData(heater, "mode", "solar", ""); err == nil {
+		t.Error("expected an unknown operation mode to be rejected")
+	}
+}
@@ -0,0 +1,321 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/cache"
+)
+
+const statesCacheKey = "states"
+
+// maxStateStaleness bounds how old cached states may be when served by
+// CachedStates while a refresh runs in the background.
+const maxStateStaleness = 24 * time.Hour
+
+// stateLoadTimeout bounds a shared load of every state, which is not
+// cancelled with the command that started it.
+const stateLoadTimeout = time.Minute
+
+// maxPatchedEntities is how many invalidated entities are re-fetched one by
+// one before falling back to reloading every state.
+const maxPatchedEntities = 10
+
+// CachingClient serves entity states from memory, seeded from an optional
+// disk cache, and invalidates the entities touched by service calls.
+type CachingClient struct {
+	Client
+
+	ttl    time.Duration
+	disk   *cache.DiskCache
+	states *cache.Cache[string, *statesSnapshot]
+	seed   sync.Once
+
+	mu          sync.Mutex
+	staleBefore time.Time
+	stale       map[string]bool
+}
+
+type statesSnapshot struct {
+	states    []EntityState
+	fetchedAt time.Time
+}
+
+var (
+	_ Client     = (*CachingClient)(nil)
+	_ StateCache = (*CachingClient)(nil)
+)
+
+// NewCachingClient wraps inner so states are reused for ttl. disk may be nil.
+func NewCachingClient(inner Client, ttl time.Duration, disk *cache.DiskCache) *CachingClient {
+	return &CachingClient{
+		Client: inner,
+		ttl:    ttl,
+		disk:   disk,
+		states: cache.New[string, *statesSnapshot](cache.Options{
+			MaxEntries:  1,
+			TTL:         ttl,
+			StaleTTL:    maxStateStaleness,
+			LoadTimeout: stateLoadTimeout,
+		}),
+		stale: make(map[string]bool),
+	}
+}
+
+func (c *CachingClient) Unwrap() Client {
+	return c.Client
+}
+
+// Close waits for background refreshes to finish.
+func (c *CachingClient) Close() {
+	c.states.Close()
+}
+
+// Invalidate drops the cached states, so the next read of any kind,
+// CachedStates included, fetches them again.
+func (c *CachingClient) Invalidate() {
+	c.states.Delete(statesCacheKey)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.staleBefore = time.Now()
+	c.stale = make(map[string]bool)
+}
+
+func (c *CachingClient) GetStates(ctx context.Context) ([]EntityState, error) {
+	c.seedFromDisk()
+
+	snapshot, stale := c.freshSnapshot()
+	if snapshot != nil && len(stale) <= maxPatchedEntities && c.patchStale(ctx, stale) {
+		if snapshot, _ = c.freshSnapshot(); snapshot != nil {
+			return snapshot.states, nil
+		}
+	}
+
+	snapshot, err := c.fetchStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.states, nil
+}
+
+// patchStale re-fetches the given entities into the cached states and
+// reports whether all of them succeeded.
+func (c *CachingClient) patchStale(ctx context.Context, entityIDs []string) bool {
+	for _, entityID := range entityIDs {
+		if _, err := c.GetState(ctx, entityID); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *CachingClient) GetState(ctx context.Context, entityID string) (*EntityState, error) {
+	c.seedFromDisk()
+
+	snapshot, stale := c.freshSnapshot()
+	if snapshot != nil && !contains(stale, entityID) {
+		for _, state := range snapshot.states {
+			if state.EntityID == entityID {
+				return &state, nil
+			}
+		}
+	}
+
+	state, err := c.Client.GetState(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot != nil {
+		c.patch(snapshot, *state)
+	}
+	return state, nil
+}
+
+func (c *CachingClient) CachedStates(ctx context.Context) ([]EntityState, time.Time, error) {
+	c.seedFromDisk()
+
+	snapshot, err := c.states.GetOrLoad(ctx, statesCacheKey, c.loadStates)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// A load that was under way when Invalidate ran returns states from
+	// before it.
+	c.mu.Lock()
+	invalidated := snapshot.fetchedAt.Before(c.staleBefore)
+	c.mu.Unlock()
+	if invalidated {
+		if snapshot, err = c.fetchStates(ctx); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	return snapshot.states, snapshot.fetchedAt, nil
+}
+
+func (c *CachingClient) CallService(ctx context.Context, domain, service string, target map[string]interface{}, serviceData map[string]interface{}) (*ServiceCallResponse, error) {
+	defer c.invalidateTargets(domain, target, serviceData)
+	return c.Client.CallService(ctx, domain, service, target, serviceData)
+}
+
+func (c *CachingClient) TurnOnEntity(ctx context.Context, entityID string) error {
+	defer c.invalidateEntity(entityID)
+	return c.Client.TurnOnEntity(ctx, entityID)
+}
+
+func (c *CachingClient) TurnOffEntity(ctx context.Context, entityID string) error {
+	defer c.invalidateEntity(entityID)
+	return c.Client.TurnOffEntity(ctx, entityID)
+}
+
+func (c *CachingClient) ToggleEntity(ctx context.Context, entityID string) error {
+	defer c.invalidateEntity(entityID)
+	return c.Client.ToggleEntity(ctx, entityID)
+}
+
+// seedFromDisk loads states written by an earlier run, keeping their age so
+// they expire when they would have if this process had fetched them.
+func (c *CachingClient) seedFromDisk() {
+	c.seed.Do(func() {
+		if c.disk == nil {
+			return
+		}
+
+		var states []EntityState
+		storedAt, ok := c.disk.Load(statesCacheKey, &states)
+		if !ok {
+			return
+		}
+
+		c.states.SetWithTTL(statesCacheKey, &statesSnapshot{states: states, fetchedAt: storedAt}, c.ttl-time.Since(storedAt))
+	})
+}
+
+// freshSnapshot returns the cached states if they are still fresh, along
+// with the entities invalidated since they were fetched.
+func (c *CachingClient) freshSnapshot() (*statesSnapshot, []string) {
+	snapshot, ok := c.states.Get(statesCacheKey)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !ok || snapshot.fetchedAt.Before(c.staleBefore) {
+		return nil, nil
+	}
+
+	stale := make([]string, 0, len(c.stale))
+	for entityID := range c.stale {
+		stale = append(stale, entityID)
+	}
+	return snapshot, stale
+}
+
+func (c *CachingClient) fetchStates(ctx context.Context) (*statesSnapshot, error) {
+	snapshot, err := c.loadStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.states.Set(statesCacheKey, snapshot)
+	return snapshot, nil
+}
+
+// loadStates fetches every state from Home Assistant and persists it.
+func (c *CachingClient) loadStates(ctx context.Context) (*statesSnapshot, error) {
+	fetchedAt := time.Now()
+	states, err := c.Client.GetStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.disk != nil {
+		_ = c.disk.Store(statesCacheKey, states)
+	}
+
+	c.mu.Lock()
+	c.stale = make(map[string]bool)
+	c.mu.Unlock()
+
+	return &statesSnapshot{states: states, fetchedAt: fetchedAt}, nil
+}
+
+// patch replaces one entity in a copy of snapshot, keeping its expiry.
+func (c *CachingClient) patch(snapshot *statesSnapshot, state EntityState) {
+	states := make([]EntityState, 0, len(snapshot.states)+1)
+	replaced := false
+	for _, existing := range snapshot.states {
+		if existing.EntityID == state.EntityID {
+			existing = state
+			replaced = true
+		}
+		states = append(states, existing)
+	}
+	if !replaced {
+		states = append(states, state)
+	}
+
+	c.states.SetWithTTL(statesCacheKey, &statesSnapshot{states: states, fetchedAt: snapshot.fetchedAt}, c.ttl-time.Since(snapshot.fetchedAt))
+
+	c.mu.Lock()
+	delete(c.stale, state.EntityID)
+	c.mu.Unlock()
+}
+
+func (c *CachingClient) invalidateEntity(entityID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stale[entityID] = true
+}
+
+// invalidateTargets marks the entities named by a service call as stale.
+// Calls without an explicit entity, or to domains that act on other
+// entities, invalidate everything.
+func (c *CachingClient) invalidateTargets(domain string, target, serviceData map[string]interface{}) {
+	entityIDs := append(targetEntityIDs(target), targetEntityIDs(serviceData)...)
+
+	switch domain {
+	case "scene", "script", "automation", "homeassistant":
+		entityIDs = nil
+	}
+
+	if len(entityIDs) == 0 {
+		c.Invalidate()
+		return
+	}
+
+	for _, entityID := range entityIDs {
+		c.invalidateEntity(entityID)
+	}
+}
+
+func targetEntityIDs(values map[string]interface{}) []string {
+	switch ids := values["entity_id"].(type) {
+	case string:
+		return []string{ids}
+	case []string:
+		return ids
+	case []interface{}:
+		var result []string
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/cache"
+)
+
+type fakeClient struct {
+	Client
+
+	mu        sync.Mutex
+	states    []EntityState
+	getStates int
+	getState  int
+
+	// hold, if set, runs before GetStates reads the states.
+	hold func()
+}
+
+func (f *fakeClient) GetStates(ctx context.Context) ([]EntityState, error) {
+	if f.hold != nil {
+		f.hold()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getStates++
+	return append([]EntityState(nil), f.states...), nil
+}
+
+func (f *fakeClient) GetState(ctx context.Context, entityID string) (*EntityState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getState++
+	for _, state := range f.states {
+		if state.EntityID == entityID {
+			return &state, nil
+		}
+	}
+	return nil, fmt.Errorf("entity %s not found", entityID)
+}
+
+func (f *fakeClient) TurnOnEntity(ctx context.Context, entityID string) error {
+	f.setState(entityID, "on")
+	return nil
+}
+
+func (f *fakeClient) CallService(ctx context.Context, domain, service string, target map[string]interface{}, serviceData map[string]interface{}) (*ServiceCallResponse, error) {
+	return &ServiceCallResponse{}, nil
+}
+
+func (f *fakeClient) setState(entityID, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.states {
+		if f.states[i].EntityID == entityID {
+			f.states[i].State = value
+		}
+	}
+}
+
+func (f *fakeClient) calls() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.getStates, f.getState
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{states: []EntityState{
+		{EntityID: "light.kitchen", State: "off"},
+		{EntityID: "light.hallway", State: "off"},
+	}}
+}
+
+func TestCachingClientReusesStates(t *testing.T) {
+	inner := newFakeClient()
+	caching := NewCachingClient(inner, time.Minute, nil)
+	defer caching.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := caching.GetStates(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	state, err := caching.GetState(context.Background(), "light.kitchen")
+	if err != nil || state.State != "off" {
+		t.Fatalf("unexpected state %+v (err=%v)", state, err)
+	}
+
+	if getStates, getState := inner.calls(); getStates != 1 || getState != 0 {
+		t.Errorf("expected one GetStates and no GetState calls, got %d and %d", getStates, getState)
+	}
+}
+
+func TestCachingClientInvalidatesTouchedEntities(t *testing.T) {
+	inner := newFakeClient()
+	caching := NewCachingClient(inner, time.Minute, nil)
+	defer caching.Close()
+
+	ctx := context.Background()
+	_, _ = caching.GetStates(ctx)
+
+	if err := caching.TurnOnEntity(ctx, "light.kitchen"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	states, err := caching.GetStates(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, state := range states {
+		if state.EntityID == "light.kitchen" && state.State != "on" {
+			t.Errorf("expected refreshed kitchen light to be on, got %s", state.State)
+		}
+	}
+
+	if getStates, getState := inner.calls(); getStates != 1 || getState != 1 {
+		t.Errorf("expected only the touched entity to be re-fetched, got %d GetStates and %d GetState", getStates, getState)
+	}
+
+	// Scenes may change any entity, so everything is reloaded.
+	_, _ = caching.CallService(ctx, "scene", "turn_on", map[string]interface{}{"entity_id": "scene.movie"}, nil)
+	_, _ = caching.GetStates(ctx)
+
+	if getStates, _ := inner.calls(); getStates != 2 {
+		t.Errorf("expected a full reload after a scene, got %d GetStates", getStates)
+	}
+}
+
+func TestCachingClientInvalidateReloadsCachedStates(t *testing.T) {
+	inner := newFakeClient()
+	caching := NewCachingClient(inner, time.Minute, nil)
+	defer caching.Close()
+
+	ctx := context.Background()
+	if _, _, err := caching.CachedStates(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, _ = caching.CachedStates(ctx)
+	if getStates, _ := inner.calls(); getStates != 1 {
+		t.Fatalf("expected cached states to be reused, got %d GetStates", getStates)
+	}
+
+	caching.Invalidate()
+	if _, _, err := caching.CachedStates(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if getStates, _ := inner.calls(); getStates != 2 {
+		t.Errorf("expected a request after Invalidate, got %d GetStates", getStates)
+	}
+}
+
+func TestCachingClientInvalidateDuringLoad(t *testing.T) {
+	inner := newFakeClient()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	inner.hold = func() {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+	}
+
+	caching := NewCachingClient(inner, time.Minute, nil)
+	defer caching.Close()
+
+	result := make(chan []EntityState, 1)
+	go func() {
+		states, _, _ := caching.CachedStates(context.Background())
+		result <- states
+	}()
+	<-started
+
+	caching.Invalidate()
+	inner.setState("light.kitchen", "on")
+	close(release)
+
+	states := <-result
+	if len(states) == 0 || states[0].State != "on" {
+		t.Errorf("expected states fetched after Invalidate, got %+v", states)
+	}
+	cached, _, _ := caching.CachedStates(context.Background())
+	if cached[0].State != "on" {
+		t.Errorf("expected the load from before Invalidate not to be cached, got %+v", cached)
+	}
+}
+
+func TestCachingClientServesStaleDiskStates(t *testing.T) {
+	disk := cache.NewDiskCache(t.TempDir(), "test", time.Second)
+	if err := disk.Store(statesCacheKey, []EntityState{{EntityID: "light.cached"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inner := newFakeClient()
+	caching := NewCachingClient(inner, 0, disk)
+
+	states, _, err := caching.CachedStates(context.Background())
+	if err != nil || len(states) != 1 || states[0].EntityID != "light.cached" {
+		t.Fatalf("expected stale disk states, got %+v (err=%v)", states, err)
+	}
+
+	caching.Close()
+
+	var stored []EntityState
+	if _, ok := disk.Load(statesCacheKey, &stored); !ok || len(stored) != 2 {
+		t.Errorf("expected background refresh to rewrite the disk cache, got %+v", stored)
+	}
+}
+
+func TestUncached(t *testing.T) {
+	inner := newFakeClient()
+	caching := NewCachingClient(inner, time.Minute, nil)
+	defer caching.Close()
+
+	if Uncached(caching) != Client(inner) {
+		t.Error("expected Uncached to return the wrapped client")
+	}
+	if Uncached(inner) != Client(inner) {
+		t.Error("expected Uncached to return an unwrapped client as is")
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +23,8 @@ type HomeAssistantClient struct {
 	httpClient *http.Client
 	baseURL    string
 	token      string
+	logger     *slog.Logger
+	tracer     *Tracer
 }
 
 type EntityState struct {
@@ -44,6 +50,17 @@ type ServiceCallResponse struct {
 	} `json:"context"`
 }
 
+type ServiceDomain struct {
+	Domain   string                        `json:"domain"`
+	Services map[string]ServiceDescription `json:"services"`
+}
+
+type ServiceDescription struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Fields      map[string]interface{} `json:"fields"`
+}
+
 type UnitSystem struct {
 	Length      string `json:"length"`
 	Mass        string `json:"mass"`
@@ -67,7 +84,7 @@ type SystemStatus struct {
 	SupportsStatistics bool       `json:"supports_statistics"`
 }
 
-func New(cfg *config.Config) *HomeAssistantClient {
+func New(cfg *config.Config, opts ...Option) *HomeAssistantClient {
 	transport := &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: cfg.HomeAssistant.SkipTLSVerify,
@@ -79,12 +96,27 @@ func New(cfg *config.Config) *HomeAssistantClient {
 		Transport: transport,
 	}
 
-	return &HomeAssistantClient{
+	client := &HomeAssistantClient{
 		config:     cfg,
 		httpClient: httpClient,
 		baseURL:    strings.TrimSuffix(cfg.HomeAssistant.URL, "/"),
 		token:      cfg.HomeAssistant.Token,
+		logger:     slog.New(slog.DiscardHandler),
+	}
+
+	for _, opt := range opts {
+		opt(client)
 	}
+
+	if client.logger.Enabled(context.Background(), slog.LevelDebug) || client.tracer != nil {
+		httpClient.Transport = &debugTransport{
+			next:   transport,
+			logger: client.logger,
+			tracer: client.tracer,
+		}
+	}
+
+	return client
 }
 
 func (c *HomeAssistantClient) TestConnection(ctx context.Context) error {
@@ -124,6 +156,15 @@ func (c *HomeAssistantClient) GetState(ctx context.Context, entityID string) (*E
 	return &state, nil
 }
 
+func (c *HomeAssistantClient) GetServices(ctx context.Context) ([]ServiceDomain, error) {
+	var services []ServiceDomain
+	err := c.makeRequest(ctx, "GET", "/api/services", nil, &services)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	return services, nil
+}
+
 func (c *HomeAssistantClient) CallService(ctx context.Context, domain, service string, target map[string]interface{}, serviceData map[string]interface{}) (*ServiceCallResponse, error) {
 	request := ServiceCallRequest{
 		Domain:      domain,
@@ -178,19 +219,51 @@ func (c *HomeAssistantClient) ToggleEntity(ctx context.Context, entityID string)
 	return err
 }
 
-func (c *HomeAssistantClient) makeRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
-	fullURL := c.baseURL + path
+// maxRetryDelay caps the backoff between attempts, including delays asked
+// for by a Retry-After header.
+const maxRetryDelay = 10 * time.Second
 
-	var reqBody io.Reader
+// maxErrorBody limits how much of an error response is kept in APIError.
+const maxErrorBody = 4096
+
+// makeRequest sends one API request, retrying failures that are safe to
+// repeat with exponential backoff and jitter.
+func (c *HomeAssistantClient) makeRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var payload []byte
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
+		payload = jsonBody
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.doRequest(ctx, method, path, payload, result)
+		if err == nil || attempt >= c.config.HomeAssistant.Retries || ctx.Err() != nil || !shouldRetry(method, err) {
+			return err
+		}
+
+		delay := retryDelay(c.config.HomeAssistant.RetryBackoff, attempt, err)
+		c.logger.Debug("retrying request", "method", method, "path", path, "attempt", attempt+1, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
 	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, fullURL, reqBody)
+func (c *HomeAssistantClient) doRequest(ctx context.Context, method, path string, payload []byte, result interface{}) error {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -200,13 +273,25 @@ func (c *HomeAssistantClient) makeRequest(ctx context.Context, method, path stri
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		if IsTimeout(err) {
+			return fmt.Errorf("request timed out: %w", err)
+		}
+		return fmt.Errorf("request failed: %w: %w", ErrUnavailable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status: %d)", string(bodyBytes), resp.StatusCode)
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		apiErr := &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       string(bodyBytes),
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
 	}
 
 	if result != nil {
@@ -216,4 +301,30 @@ func (c *HomeAssistantClient) makeRequest(ctx context.Context, method, path stri
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// shouldRetry allows retries of idempotent GETs on transient failures, and
+// of any method when the connection was never established.
+func shouldRetry(method string, err error) bool {
+	if isDialError(err) {
+		return true
+	}
+	if method != http.MethodGet {
+		return false
+	}
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrRateLimited) || IsTimeout(err)
+}
+
+func retryDelay(base time.Duration, attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, maxRetryDelay)
+	}
+
+	if base <= 0 {
+		return 0
+	}
+
+	delay := min(base<<attempt, maxRetryDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
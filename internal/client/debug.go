@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxLoggedBody is how much of a request or response body debug logs show.
+const maxLoggedBody = 512
+
+const redacted = "***REDACTED***"
+
+// sensitiveKeys are JSON fields whose values never appear in debug logs.
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"password":      true,
+	"api_key":       true,
+	"code":          true,
+}
+
+// Option customizes a client created by New.
+type Option func(*HomeAssistantClient)
+
+// WithLogger logs every request at debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *HomeAssistantClient) {
+		c.logger = logger
+	}
+}
+
+// WithTracer records every request/response pair to tracer.
+func WithTracer(tracer *Tracer) Option {
+	return func(c *HomeAssistantClient) {
+		c.tracer = tracer
+	}
+}
+
+// debugTransport logs and traces requests passing through next.
+type debugTransport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+	tracer *Tracer
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.logger.Debug("request failed",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"latency", elapsed,
+			"error", err,
+		)
+		t.tracer.record(req, requestBody, nil, nil, start, elapsed, err)
+		return nil, err
+	}
+
+	responseBody, err := drainBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	t.logger.Debug("request",
+		"method", req.Method,
+		"path", req.URL.Path,
+		"status", resp.StatusCode,
+		"latency", elapsed,
+		"request_body", redactBody(requestBody),
+		"response_body", redactBody(responseBody),
+	)
+	t.tracer.record(req, requestBody, resp, responseBody, start, elapsed, nil)
+
+	return resp, nil
+}
+
+// drainBody reads body fully and replaces it with an in-memory copy.
+func drainBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, err
+}
+
+// redactBody masks sensitive JSON fields and truncates the result.
+func redactBody(body []byte) string {
+	text := redactJSON(body)
+	if len(text) > maxLoggedBody {
+		text = text[:maxLoggedBody] + "…"
+	}
+	return text
+}
+
+// redactJSON masks sensitive fields in a JSON body. Bodies that are not JSON
+// are returned as they are.
+func redactJSON(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redactedJSON, err := json.Marshal(redactValue(value)); err == nil {
+			return string(redactedJSON)
+		}
+	}
+	return string(body)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if sensitiveKeys[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = redactValue(nested)
+		}
+	}
+	return value
+}
+
+// maskHeaders copies headers with credentials replaced.
+func maskHeaders(header http.Header) http.Header {
+	masked := header.Clone()
+	for _, name := range []string{"Authorization", "Cookie", "Set-Cookie"} {
+		if masked.Get(name) != "" {
+			masked.Set(name, redacted)
+		}
+	}
+	return masked
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/config"
+)
+
+func newDebugTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"entity_id":"light.kitchen","state":"on","attributes":{"access_token":"abc123"}}]`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newDebugTestConfig(url string) *config.Config {
+	return &config.Config{
+		HomeAssistant: config.HomeAssistantConfig{
+			URL:     url,
+			Token:   "secret-token",
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+func TestDebugLoggingRedacts(t *testing.T) {
+	server := newDebugTestServer(t)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if _, err := New(newDebugTestConfig(server.URL), WithLogger(logger)).GetStates(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := logs.String()
+	for _, want := range []string{"method=GET", "path=/api/states", "status=200", "latency="} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected log to contain %q, got %s", want, output)
+		}
+	}
+	for _, secret := range []string{"secret-token", "abc123"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, output)
+		}
+	}
+}
+
+func TestTracerJSONL(t *testing.T) {
+	server := newDebugTestServer(t)
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+
+	tracer, err := NewTracer(path, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := New(newDebugTestConfig(server.URL), WithTracer(tracer))
+	if _, err := client.GetStates(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tracer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("expected Authorization header to be masked in the trace")
+	}
+
+	var record traceRecord
+	if err := json.Unmarshal(bytes.TrimSpace(data), &record); err != nil {
+		t.Fatalf("expected one JSON line, got %s: %v", data, err)
+	}
+	if record.Method != "GET" || record.Status != 200 || !strings.Contains(record.ResponseBody, "light.kitchen") {
+		t.Errorf("unexpected trace record: %+v", record)
+	}
+}
+
+func TestTracerHAR(t *testing.T) {
+	server := newDebugTestServer(t)
+	path := filepath.Join(t.TempDir(), "trace.har")
+
+	tracer, err := NewTracer(path, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := New(newDebugTestConfig(server.URL), WithTracer(tracer))
+	_, _ = client.GetStates(context.Background())
+	_, _ = client.CallService(context.Background(), "light", "turn_on", map[string]interface{}{"entity_id": "light.kitchen"}, nil)
+	if err := tracer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var archive struct {
+		Log struct {
+			Version string     `json:"version"`
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &archive); err != nil {
+		t.Fatalf("expected a HAR document: %v", err)
+	}
+	if archive.Log.Version != "1.2" || len(archive.Log.Entries) != 2 {
+		t.Fatalf("unexpected HAR log: %+v", archive.Log)
+	}
+	if post := archive.Log.Entries[1].Request.PostData; post == nil || !strings.Contains(post.Text, "light.kitchen") {
+		t.Errorf("expected service call body in HAR, got %+v", post)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("expected Authorization header to be masked in the HAR")
+	}
+}
+
+func TestTracerRedactsBodies(t *testing.T) {
+	for _, name := range []string{"trace.jsonl", "trace.har"} {
+		server := newDebugTestServer(t)
+		path := filepath.Join(t.TempDir(), name)
+
+		tracer, err := NewTracer(path, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		client := New(newDebugTestConfig(server.URL), WithTracer(tracer))
+		_, _ = client.GetStates(context.Background())
+		_, _ = client.CallService(context.Background(), "alarm_control_panel", "alarm_disarm",
+			map[string]interface{}{"entity_id": "alarm_control_panel.home"},
+			map[string]interface{}{"code": "4321"})
+		if err := tracer.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Contains(string(data), "4321") {
+			t.Errorf("%s: expected the code to be redacted, got %s", name, data)
+		}
+		if strings.Contains(string(data), "abc123") {
+			t.Errorf("%s: expected the response token to be redacted, got %s", name, data)
+		}
+		if !strings.Contains(string(data), "alarm_control_panel.home") {
+			t.Errorf("%s: expected the rest of the body to be kept, got %s", name, data)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/config"
+)
+
+func newRetryTestClient(url string, retries int) *HomeAssistantClient {
+	return New(&config.Config{
+		HomeAssistant: config.HomeAssistantConfig{
+			URL:          url,
+			Token:        "test-token",
+			Timeout:      5 * time.Second,
+			Retries:      retries,
+			RetryBackoff: time.Millisecond,
+		},
+	})
+}
+
+func TestMakeRequestTypedErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusServiceUnavailable, ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", tt.status)
+		}))
+
+		_, err := newRetryTestClient(server.URL, 0).GetStates(context.Background())
+		server.Close()
+
+		if !errors.Is(err, tt.want) {
+			t.Errorf("status %d: expected %v, got %v", tt.status, tt.want, err)
+		}
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || apiErr.Body != "nope\n" {
+			t.Errorf("status %d: expected APIError with body, got %#v", tt.status, err)
+		}
+	}
+}
+
+func TestMakeRequestRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	if _, err := newRetryTestClient(server.URL, 2).GetStates(context.Background()); err != nil {
+		t.Fatalf("expected GET to succeed after retries, got %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", requests.Load())
+	}
+
+	requests.Store(0)
+	if _, err := newRetryTestClient(server.URL, 2).CallService(context.Background(), "light", "turn_on", nil, nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected POST to fail without retrying, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected POST to be sent once, got %d", requests.Load())
+	}
+}
+
+func TestMakeRequestConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	_, err := newRetryTestClient(url, 1).CallService(context.Background(), "light", "turn_on", nil, nil)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable for a refused connection, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// Client is the Home Assistant API used by the resolver, CLI and TUI.
+type Client interface {
+	TestConnection(ctx context.Context) error
+	GetSystemStatus(ctx context.Context) (*SystemStatus, error)
+	GetStates(ctx context.Context) ([]EntityState, error)
+	GetState(ctx context.Context, entityID string) (*EntityState, error)
+	GetServices(ctx context.Context) ([]ServiceDomain, error)
+	CallService(ctx context.Context, domain, service string, target map[string]interface{}, serviceData map[string]interface{}) (*ServiceCallResponse, error)
+	TurnOnEntity(ctx context.Context, entityID string) error
+	TurnOffEntity(ctx context.Context, entityID string) error
+	ToggleEntity(ctx context.Context, entityID string) error
+}
+
+// StateCache is implemented by clients that keep entity states locally.
+type StateCache interface {
+	// CachedStates returns states that may be stale, refreshing them in the
+	// background, together with the time they were fetched.
+	CachedStates(ctx context.Context) ([]EntityState, time.Time, error)
+	// Invalidate makes the next read fetch states from Home Assistant.
+	Invalidate()
+}
+
+var _ Client = (*HomeAssistantClient)(nil)
+
+// Uncached returns the client underneath any caching layers, for callers
+// such as polling loops that always need live data.
+func Uncached(c Client) Client {
+	for {
+		wrapper, ok := c.(interface{ Unwrap() Client })
+		if !ok {
+			return c
+		}
+		c = wrapper.Unwrap()
+	}
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer writes full request/response pairs to a file for bug reports,
+// either as JSON lines or, for paths ending in .har, as a HAR archive.
+// Credentials in headers and sensitive body fields in both directions, such
+// as lock and alarm codes or access tokens, are always masked.
+type Tracer struct {
+	mu      sync.Mutex
+	file    *os.File
+	har     bool
+	version string
+	entries []harEntry
+}
+
+type traceRecord struct {
+	Time            time.Time   `json:"time"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"request_headers"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	Status          int         `json:"status,omitempty"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+	DurationMS      float64     `json:"duration_ms"`
+	Error           string      `json:"error,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewTracer creates path with owner-only permissions. version is recorded as
+// the HAR creator version.
+func NewTracer(path, version string) (*Tracer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace file: %w", err)
+	}
+
+	return &Tracer{
+		file:    file,
+		har:     strings.HasSuffix(strings.ToLower(path), ".har"),
+		version: version,
+	}, nil
+}
+
+// Close writes the HAR archive, if any, and closes the file.
+func (t *Tracer) Close() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.har {
+		archive := map[string]interface{}{
+			"log": map[string]interface{}{
+				"version": "1.2",
+				"creator": map[string]string{"name": "hass-cli", "version": t.version},
+				"entries": append([]harEntry{}, t.entries...),
+			},
+		}
+
+		encoder := json.NewEncoder(t.file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(archive); err != nil {
+			_ = t.file.Close()
+			return fmt.Errorf("failed to write trace file: %w", err)
+		}
+	}
+
+	return t.file.Close()
+}
+
+func (t *Tracer) record(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte, start time.Time, elapsed time.Duration, err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	durationMS := float64(elapsed.Microseconds()) / 1000
+
+	if t.har {
+		t.entries = append(t.entries, newHAREntry(req, requestBody, resp, responseBody, start, durationMS, err))
+		return
+	}
+
+	record := traceRecord{
+		Time:           start,
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: maskHeaders(req.Header),
+		RequestBody:    redactJSON(requestBody),
+		DurationMS:     durationMS,
+	}
+	if resp != nil {
+		record.Status = resp.StatusCode
+		record.ResponseHeaders = maskHeaders(resp.Header)
+		record.ResponseBody = redactJSON(responseBody)
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	_ = json.NewEncoder(t.file).Encode(record)
+}
+
+func newHAREntry(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte, start time.Time, durationMS float64, err error) harEntry {
+	entry := harEntry{
+		StartedDateTime: start,
+		Time:            durationMS,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(maskHeaders(req.Header)),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(requestBody),
+		},
+		Timings: harTimings{Wait: durationMS},
+	}
+
+	if requestBody != nil {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     redactJSON(requestBody),
+		}
+	}
+
+	if resp != nil {
+		entry.Response = harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Headers:     harHeaders(maskHeaders(resp.Header)),
+			Content: harContent{
+				Size:     len(responseBody),
+				MimeType: resp.Header.Get("Content-Type"),
+				Text:     redactJSON(responseBody),
+			},
+			HeadersSize: -1,
+			BodySize:    len(responseBody),
+		}
+	} else {
+		entry.Response = harResponse{Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1}
+	}
+
+	if err != nil {
+		entry.Comment = err.Error()
+	}
+
+	return entry
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].Name < headers[j].Name
+	})
+	return headers
+}
@@ -4,18 +4,39 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
+	Version       int                 `yaml:"version"`
 	HomeAssistant HomeAssistantConfig `yaml:"homeassistant"`
 	Aliases       map[string]string   `yaml:"aliases"`
+	Macros        map[string][]string `yaml:"macros"`
 	Preferences   PreferencesConfig   `yaml:"preferences"`
 	Output        OutputConfig        `yaml:"output"`
 	Discovery     DiscoveryConfig     `yaml:"discovery"`
 	Security      SecurityConfig      `yaml:"security"`
+	TUI           TUIConfig           `yaml:"tui"`
+
+	// tokenFromStore records that HomeAssistant.Token came from a token
+	// store, so Save never writes it back to config.yaml.
+	tokenFromStore bool
+
+	// warnings holds non-fatal problems found by Load.
+	warnings []Problem
+
+	// migration records how Load upgraded an older config.yaml.
+	migration *MigrationReport
+
+	// resolvedTags records that values came from !secret, !env or !include,
+	// so Save refuses to flatten them into config.yaml.
+	resolvedTags bool
+
+	// sources lists config.yaml and every file it pulled values from.
+	sources []string
 }
 
 type HomeAssistantConfig struct {
@@ -24,12 +45,15 @@ type HomeAssistantConfig struct {
 	Timeout        time.Duration `yaml:"timeout"`
 	SkipTLSVerify  bool          `yaml:"skip_tls_verify"`
 	CacheTimeout   time.Duration `yaml:"cache_timeout"`
+	Retries        int           `yaml:"retries"`
+	RetryBackoff   time.Duration `yaml:"retry_backoff"`
 }
 
 type PreferencesConfig struct {
-	FuzzyThreshold float64 `yaml:"fuzzy_threshold"`
-	DefaultArea    string  `yaml:"default_area"`
-	AutoDiscovery  bool    `yaml:"auto_discovery"`
+	FuzzyThreshold     float64       `yaml:"fuzzy_threshold"`
+	DefaultArea        string        `yaml:"default_area"`
+	AutoDiscovery      bool          `yaml:"auto_discovery"`
+	ConfirmDestructive ConfirmPolicy `yaml:"confirm_destructive"`
 }
 
 type OutputConfig struct {
@@ -50,19 +74,38 @@ type SecurityConfig struct {
 	UseKeyring       bool `yaml:"use_keyring"`
 	KeyringService   string `yaml:"keyring_service"`
 	ConfigFilePerms  os.FileMode `yaml:"config_file_perms"`
+	TokenStore       string `yaml:"token_store,omitempty"`
+	TokenFile        string `yaml:"token_file,omitempty"`
+	TokenCommand     string `yaml:"token_command,omitempty"`
 }
 
+type TUIConfig struct {
+	Theme       string            `yaml:"theme"`
+	Keybindings map[string]string `yaml:"keybindings,omitempty"`
+}
+
+// TUI themes and the actions keybindings can be set for.
+var (
+	TUIThemes  = []string{"default", "light", "mono"}
+	TUIActions = []string{"up", "down", "toggle", "refresh", "quit"}
+)
+
 func DefaultConfig() *Config {
 	return &Config{
+		Version: CurrentVersion,
 		HomeAssistant: HomeAssistantConfig{
 			Timeout:       10 * time.Second,
 			SkipTLSVerify: false,
 			CacheTimeout:  5 * time.Minute,
+			Retries:       2,
+			RetryBackoff:  500 * time.Millisecond,
 		},
 		Aliases: make(map[string]string),
+		Macros:  make(map[string][]string),
 		Preferences: PreferencesConfig{
-			FuzzyThreshold: 0.5,
-			AutoDiscovery:  true,
+			FuzzyThreshold:     0.5,
+			AutoDiscovery:      true,
+			ConfirmDestructive: slices.Clone(DefaultConfirmActions),
 		},
 		Output: OutputConfig{
 			Format:    "text",
@@ -80,16 +123,62 @@ func DefaultConfig() *Config {
 			KeyringService:  "hass-cli",
 			ConfigFilePerms: 0600,
 		},
+		TUI: TUIConfig{
+			Theme: "default",
+		},
 	}
 }
 
+// Load reads config.yaml, upgrading it in place when it uses an older layout.
 func Load() (*Config, error) {
+	return load(true)
+}
+
+// LoadReadOnly reads config.yaml like Load but never rewrites it; older
+// layouts are only migrated in memory.
+func LoadReadOnly() (*Config, error) {
+	return load(false)
+}
+
+// LoadWithoutToken reads config.yaml like Load but leaves the token store
+// alone, for commands that never talk to Home Assistant. They then neither
+// prompt for a passphrase nor fail on a broken token store.
+func LoadWithoutToken() (*Config, error) {
 	configPath, err := getConfigPath()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config path: %w", err)
 	}
 
+	return readFile(configPath, true)
+}
+
+func load(migrate bool) (*Config, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	return loadFile(configPath, migrate)
+}
+
+func loadFile(configPath string, migrate bool) (*Config, error) {
+	cfg, err := readFile(configPath, migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadToken(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// readFile reads configPath like loadFile but leaves the token store alone,
+// so a reload neither prompts for a passphrase nor runs token_command again.
+func readFile(configPath string, migrate bool) (*Config, error) {
 	cfg := DefaultConfig()
+	cfg.sources = []string{configPath}
 
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return cfg, nil
@@ -100,9 +189,29 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+	var migration *MigrationReport
+	if migrate {
+		migrated, report, err := Migrate(data)
+		if err == nil && report != nil && len(report.Changes) > 0 {
+			if report.Backup, err = rewriteConfig(configPath, data, migrated); err != nil {
+				return nil, fmt.Errorf("failed to migrate config: %w", err)
+			}
+			data, migration = migrated, report
+		}
+	}
+
+	cfg, problems := parse(data, configPath)
+	if HasErrors(problems) {
+		var failures []Problem
+		for _, problem := range problems {
+			if !problem.Warning {
+				failures = append(failures, problem)
+			}
+		}
+		return nil, &ValidationError{File: configPath, Problems: failures}
 	}
+	cfg.warnings = problems
+	cfg.migration = migration
 
 	return cfg, nil
 }
@@ -113,11 +222,20 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to get config path: %w", err)
 	}
 
+	if c.resolvedTags {
+		return fmt.Errorf("%s uses !secret, !env or !include; edit it by hand instead", configPath)
+	}
+
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(c)
+	saved := *c
+	if c.tokenFromStore {
+		saved.HomeAssistant.Token = ""
+	}
+
+	data, err := yaml.Marshal(&saved)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -92,4 +92,31 @@ func TestConfigSaveAndLoad(t *testing.T) {
 	if loadedCfg.Preferences.FuzzyThreshold != 0.8 {
 		t.Errorf("expected FuzzyThreshold to be 0.8, got %v", loadedCfg.Preferences.FuzzyThreshold)
 	}
-}
\ No newline at end of file
+}
+
+func TestValidateMacros(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Macros = map[string][]string{
+		"movie":   {"living lights brightness 40", "scene Movie"},
+		"evening": {"movie", "kitchen lights off"},
+	}
+
+	if err := cfg.ValidateMacros(); err != nil {
+		t.Fatalf("expected valid macros, got %v", err)
+	}
+
+	cfg.Macros["movie"] = append(cfg.Macros["movie"], "evening")
+	if err := cfg.ValidateMacros(); err == nil {
+		t.Error("expected recursion to be rejected")
+	}
+
+	cfg.Macros = map[string][]string{"empty": {}}
+	if err := cfg.ValidateMacros(); err == nil {
+		t.Error("expected macro without steps to be rejected")
+	}
+
+	cfg.Macros = map[string][]string{"status": {"living lights on"}}
+	if err := cfg.ValidateMacros(); err == nil {
+		t.Error("expected macro named after a builtin command to be rejected")
+	}
+}
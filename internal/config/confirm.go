@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AuditLogFile records lock and alarm actions, next to config.yaml.
+const AuditLogFile = "audit.log"
+
+// DefaultConfirmActions ask for confirmation unless
+// preferences.confirm_destructive says otherwise.
+var DefaultConfirmActions = []string{"lock.unlock", "lock.open", "alarm_control_panel.alarm_disarm", "garage.open_cover"}
+
+// ConfirmPolicy lists the service calls that ask before running. Each entry
+// is a domain ("lock"), or a domain or device class with a service
+// ("lock.unlock", "garage.open_cover"). In YAML it may also be true, for
+// DefaultConfirmActions, or false, to never ask.
+type ConfirmPolicy []string
+
+func (p *ConfirmPolicy) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!bool" {
+		var enabled bool
+		if err := node.Decode(&enabled); err != nil {
+			return err
+		}
+		if enabled {
+			*p = slices.Clone(DefaultConfirmActions)
+		} else {
+			*p = ConfirmPolicy{}
+		}
+		return nil
+	}
+
+	var actions []string
+	if err := node.Decode(&actions); err != nil {
+		return &yaml.TypeError{Errors: []string{
+			fmt.Sprintf("line %d: confirm_destructive must be true, false or a list such as [lock.unlock, garage.open_cover]", node.Line),
+		}}
+	}
+	*p = actions
+	return nil
+}
+
+// Requires reports whether calling service on an entity in domain, with an
+// optional device class, needs confirmation.
+func (p ConfirmPolicy) Requires(domain, deviceClass, service string) bool {
+	for _, rule := range p {
+		scope, ruleService, hasService := strings.Cut(rule, ".")
+		if scope != domain && (deviceClass == "" || scope != deviceClass) {
+			continue
+		}
+		if !hasService || ruleService == service {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditLogPath returns the path of the audit log.
+func AuditLogPath() (string, error) {
+	path, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), AuditLogFile), nil
+}
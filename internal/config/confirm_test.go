@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfirmPolicyYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want ConfirmPolicy
+	}{
+		{"unset", "preferences:\n  fuzzy_threshold: 0.5\n", DefaultConfirmActions},
+		{"true", "preferences:\n  confirm_destructive: true\n", DefaultConfirmActions},
+		{"false", "preferences:\n  confirm_destructive: false\n", ConfirmPolicy{}},
+		{"list", "preferences:\n  confirm_destructive: [lock, switch.turn_off]\n", ConfirmPolicy{"lock", "switch.turn_off"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, problems := Parse([]byte(tt.yaml))
+			if len(problems) > 0 {
+				t.Fatalf("unexpected problems: %v", problems)
+			}
+			if !reflect.DeepEqual(cfg.Preferences.ConfirmDestructive, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, cfg.Preferences.ConfirmDestructive)
+			}
+		})
+	}
+}
+
+func TestConfirmPolicyProblems(t *testing.T) {
+	for _, yaml := range []string{
+		"preferences:\n  confirm_destructive: sometimes\n",
+		"preferences:\n  confirm_destructive: [lock.unlock.now]\n",
+	} {
+		_, problems := Parse([]byte(yaml))
+		if len(problems) != 1 || problems[0].Line != 2 || !strings.Contains(problems[0].String(), "confirm_destructive") {
+			t.Errorf("%q: expected one problem on line 2, got %v", yaml, problems)
+		}
+	}
+}
+
+func TestConfirmPolicyRequires(t *testing.T) {
+	policy := ConfirmPolicy{"lock", "garage.open_cover", "switch.turn_off"}
+
+	tests := []struct {
+		domain, deviceClass, service string
+		want                         bool
+	}{
+		{"lock", "", "lock", true},
+		{"cover", "garage", "open_cover", true},
+		{"cover", "blind", "open_cover", false},
+		{"cover", "garage", "close_cover", false},
+		{"switch", "outlet", "turn_off", true},
+		{"switch", "", "turn_on", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Requires(tt.domain, tt.deviceClass, tt.service); got != tt.want {
+			t.Errorf("Requires(%q, %q, %q) = %v, expected %v", tt.domain, tt.deviceClass, tt.service, got, tt.want)
+		}
+	}
+}
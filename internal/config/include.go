@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretsFile is read for !secret values, from the directory of config.yaml.
+const SecretsFile = "secrets.yaml"
+
+// resolver replaces the !secret, !env and !include tags in a document.
+type resolver struct {
+	dir       string
+	secrets   *yaml.Node
+	including []string
+	used      bool
+	// files lists every file read, starting with config.yaml.
+	files []string
+}
+
+func newResolver(configPath string) *resolver {
+	path, err := filepath.Abs(configPath)
+	if err != nil {
+		path = configPath
+	}
+	return &resolver{dir: filepath.Dir(path), including: []string{path}, files: []string{path}}
+}
+
+// resolve walks node, replacing tagged nodes in place. dir is the directory
+// of the file node came from, which !include paths are relative to.
+func (r *resolver) resolve(node *yaml.Node, dir string, problems *[]Problem) {
+	switch node.Tag {
+	case "!secret", "!env", "!include":
+		if err := r.replace(node, dir); err != nil {
+			*problems = append(*problems, Problem{
+				Line:    node.Line,
+				Column:  node.Column,
+				Message: fmt.Sprintf("%s %s: %v", node.Tag, node.Value, err),
+			})
+			// Leave an empty value so decoding can still report other problems.
+			*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: node.Line, Column: node.Column}
+		}
+		return
+	}
+
+	for _, child := range node.Content {
+		r.resolve(child, dir, problems)
+	}
+}
+
+func (r *resolver) replace(node *yaml.Node, dir string) error {
+	if node.Kind != yaml.ScalarNode || node.Value == "" {
+		return fmt.Errorf("expects a name")
+	}
+	r.used = true
+	line, column := node.Line, node.Column
+
+	switch node.Tag {
+	case "!secret":
+		value, err := r.secret(node.Value)
+		if err != nil {
+			return err
+		}
+		*node = *value
+		node.Line, node.Column = line, column
+	case "!env":
+		value, ok := os.LookupEnv(node.Value)
+		if !ok {
+			return fmt.Errorf("environment variable is not set")
+		}
+		*node = yaml.Node{Kind: yaml.ScalarNode, Value: value, Line: node.Line, Column: node.Column}
+	case "!include":
+		value, err := r.include(node.Value, dir)
+		if err != nil {
+			return err
+		}
+		// Report problems in included values at the !include line.
+		movePosition(value, line, column)
+		*node = *value
+	}
+
+	return nil
+}
+
+func (r *resolver) secret(name string) (*yaml.Node, error) {
+	if r.secrets == nil {
+		path := filepath.Join(r.dir, SecretsFile)
+		r.files = append(r.files, path)
+		document, err := readDocument(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no %s next to config.yaml", SecretsFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if document.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s must be a mapping of names to values", SecretsFile)
+		}
+		r.secrets = document
+	}
+
+	value := mappingValue(r.secrets, name)
+	if value == nil {
+		return nil, fmt.Errorf("not found in %s", SecretsFile)
+	}
+	if value.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("secrets must be plain values")
+	}
+	return value, nil
+}
+
+func (r *resolver) include(name, dir string) (*yaml.Node, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	for i, including := range r.including {
+		if including == path {
+			var cycle []string
+			for _, p := range append(r.including[i:len(r.including):len(r.including)], path) {
+				cycle = append(cycle, filepath.Base(p))
+			}
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " → "))
+		}
+	}
+
+	r.files = append(r.files, path)
+	document, err := readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	r.including = append(r.including, path)
+	r.resolve(document, filepath.Dir(path), &problems)
+	r.including = r.including[:len(r.including)-1]
+
+	if len(problems) > 0 {
+		// Positions inside the included file would be misleading here.
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = fmt.Sprintf("line %d: %s", problem.Line, problem.Message)
+		}
+		return nil, fmt.Errorf("in %s: %s", filepath.Base(path), strings.Join(messages, "; "))
+	}
+
+	return document, nil
+}
+
+func movePosition(node *yaml.Node, line, column int) {
+	node.Line, node.Column = line, column
+	for _, child := range node.Content {
+		movePosition(child, line, column)
+	}
+}
+
+func readDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	if len(root.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}, nil
+	}
+	return root.Content[0], nil
+}
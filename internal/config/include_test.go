@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestParseFileResolvesTags(t *testing.T) {
+	t.Setenv("HASS_TEST_URL", "http://ha.example.com:8123")
+
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": `homeassistant:
+  url: !env HASS_TEST_URL
+  token: !secret ha_token
+  retries: !secret retries
+aliases: !include shared/aliases.yaml
+`,
+		"secrets.yaml": "ha_token: abc123\nretries: 4\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "shared"), 0700); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shared", "aliases.yaml"), []byte("lr: living room\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, problems, err := ParseFile(filepath.Join(dir, "config.yaml"))
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v (err=%v)", problems, err)
+	}
+
+	if cfg.HomeAssistant.URL != "http://ha.example.com:8123" {
+		t.Errorf("expected URL from the environment, got %q", cfg.HomeAssistant.URL)
+	}
+	if cfg.HomeAssistant.Token != "abc123" || cfg.HomeAssistant.Retries != 4 {
+		t.Errorf("expected values from secrets.yaml, got %+v", cfg.HomeAssistant)
+	}
+	if cfg.Aliases["lr"] != "living room" {
+		t.Errorf("expected included aliases, got %v", cfg.Aliases)
+	}
+	if err := cfg.Save(); err == nil {
+		t.Error("expected Save to refuse a config built from tags")
+	}
+}
+
+func TestParseFileTagErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		message string
+	}{
+		{
+			name:    "missing secrets file",
+			files:   map[string]string{"config.yaml": "homeassistant:\n  token: !secret ha_token\n"},
+			message: "no secrets.yaml",
+		},
+		{
+			name: "missing secret",
+			files: map[string]string{
+				"config.yaml":  "homeassistant:\n  token: !secret ha_token\n",
+				"secrets.yaml": "other: value\n",
+			},
+			message: "not found in secrets.yaml",
+		},
+		{
+			name:    "unset variable",
+			files:   map[string]string{"config.yaml": "homeassistant:\n  token: !env HASS_TEST_UNSET\n"},
+			message: "environment variable is not set",
+		},
+		{
+			name:    "missing include",
+			files:   map[string]string{"config.yaml": "aliases: !include missing.yaml\n"},
+			message: "no such file",
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"config.yaml": "macros: !include a.yaml\n",
+				"a.yaml":      "evening: !include b.yaml\n",
+				"b.yaml":      "- !include a.yaml\n",
+			},
+			message: "include cycle: a.yaml → b.yaml → a.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+
+			_, problems, err := ParseFile(filepath.Join(dir, "config.yaml"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(problems) != 1 || problems[0].Line == 0 {
+				t.Fatalf("expected one positioned problem, got %v", problems)
+			}
+			if !strings.Contains(problems[0].Message, tt.message) {
+				t.Errorf("expected message containing %q, got %q", tt.message, problems[0].Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// BuiltinCommands are the commands hass handles itself. Macros cannot take
+// their names, since the command would always win.
+var BuiltinCommands = []string{
+	"config", "status", "tui", "shell", "discover", "automation", "scene", "wait",
+	"run", "completion", "cache", "debug", "help", "version",
+}
+
+// ValidateMacros checks that every macro has at least one step, does not
+// shadow a builtin command, and that macros calling other macros never
+// recurse back into themselves.
+func (c *Config) ValidateMacros() error {
+	names := make([]string, 0, len(c.Macros))
+	for name := range c.Macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if slices.Contains(BuiltinCommands, name) {
+			return fmt.Errorf("macro %q has the name of a builtin command", name)
+		}
+		if len(c.Macros[name]) == 0 {
+			return fmt.Errorf("macro %q has no steps", name)
+		}
+	}
+
+	visited := make(map[string]bool)
+	for _, name := range names {
+		if err := c.checkMacroCycle(name, nil, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) checkMacroCycle(name string, stack []string, visited map[string]bool) error {
+	for i, caller := range stack {
+		if caller == name {
+			cycle := append(append([]string{}, stack[i:]...), name)
+			return fmt.Errorf("macro %q is recursive: %s", name, strings.Join(cycle, " → "))
+		}
+	}
+
+	if visited[name] {
+		return nil
+	}
+
+	stack = append(stack, name)
+	for _, step := range c.Macros[name] {
+		fields := strings.Fields(step)
+		if len(fields) == 0 {
+			return fmt.Errorf("macro %q has an empty step", name)
+		}
+		if _, isMacro := c.Macros[fields[0]]; isMacro {
+			if err := c.checkMacroCycle(fields[0], stack, visited); err != nil {
+				return err
+			}
+		}
+	}
+
+	visited[name] = true
+	return nil
+}
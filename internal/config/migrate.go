@@ -0,0 +1,277 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config layout written by this build. Files without a
+// version field are version 0, which includes the layout from specs/config.md.
+const CurrentVersion = 1
+
+// migration upgrades a document from version from to from+1 in place and
+// returns a description of each change.
+type migration struct {
+	from  int
+	apply func(document *yaml.Node) []string
+}
+
+var migrations = []migration{
+	{from: 0, apply: migrateSpecLayout},
+}
+
+// MigrationReport describes an upgrade of a config file.
+type MigrationReport struct {
+	From    int
+	To      int
+	Changes []string
+	// Backup is the copy of the original file, set once it has been written.
+	Backup string
+}
+
+// Migration returns the migration Load applied to config.yaml, if any.
+func (c *Config) Migration() *MigrationReport {
+	return c.migration
+}
+
+// Migrate upgrades config YAML to CurrentVersion, keeping comments. The
+// report is nil when the data is already current.
+func Migrate(data []byte) ([]byte, *MigrationReport, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if len(root.Content) == 0 {
+		return data, nil, nil
+	}
+
+	report, err := migrateDocument(root.Content[0])
+	if err != nil || report == nil {
+		return data, nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	return buf.Bytes(), report, nil
+}
+
+// MigrateFile upgrades the config file at path, writing a timestamped backup
+// of the original next to it first. With dryRun nothing is written.
+func MigrateFile(path string, dryRun bool) (*MigrationReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	migrated, report, err := Migrate(data)
+	if err != nil || report == nil || dryRun {
+		return report, err
+	}
+
+	if report.Backup, err = rewriteConfig(path, data, migrated); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func migrateDocument(document *yaml.Node) (*MigrationReport, error) {
+	if document.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	version := 0
+	versionNode := mappingValue(document, "version")
+	if versionNode != nil {
+		parsed, err := strconv.Atoi(versionNode.Value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: version must be a whole number, got %q", versionNode.Line, versionNode.Value)
+		}
+		version = parsed
+	}
+	if version >= CurrentVersion {
+		return nil, nil
+	}
+
+	report := &MigrationReport{From: version, To: CurrentVersion}
+	for _, m := range migrations {
+		if m.from >= version {
+			report.Changes = append(report.Changes, m.apply(document)...)
+		}
+	}
+
+	if versionNode != nil {
+		versionNode.Value = strconv.Itoa(CurrentVersion)
+	} else {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}
+		document.Content = append([]*yaml.Node{key, value}, document.Content...)
+	}
+
+	return report, nil
+}
+
+// migrateSpecLayout maps the keys documented in specs/config.md onto the
+// fields hass-cli actually reads.
+func migrateSpecLayout(document *yaml.Node) []string {
+	var changes []string
+
+	if homeAssistant := mappingValue(document, "homeassistant"); homeAssistant != nil {
+		if key, value := takeKey(homeAssistant, "verify_ssl"); key != nil {
+			if verify, err := strconv.ParseBool(value.Value); err == nil {
+				value.Value = strconv.FormatBool(!verify)
+				changes = append(changes, moveKey(homeAssistant, key, value, "homeassistant.verify_ssl", "skip_tls_verify", "homeassistant.skip_tls_verify"))
+			} else {
+				homeAssistant.Content = append(homeAssistant.Content, key, value)
+			}
+		}
+	}
+
+	if output := mappingValue(document, "output"); output != nil {
+		if format := mappingValue(output, "format"); format != nil && format.Value == "pretty" {
+			format.Value = "text"
+			changes = append(changes, `output.format: "pretty" is now "text"`)
+		}
+	}
+
+	preferences := mappingValue(document, "preferences")
+	if preferences == nil {
+		return changes
+	}
+
+	if key, value := takeKey(preferences, "cache_ttl"); key != nil {
+		changes = append(changes, moveKey(section(document, "homeassistant"), key, value, "preferences.cache_ttl", "cache_timeout", "homeassistant.cache_timeout"))
+	}
+	if key, value := takeKey(preferences, "cache_entities"); key != nil {
+		if enabled, err := strconv.ParseBool(value.Value); err == nil && !enabled {
+			value.Value = "0s"
+			value.Tag = "!!str"
+			homeAssistant := section(document, "homeassistant")
+			takeKey(homeAssistant, "cache_timeout")
+			changes = append(changes, moveKey(homeAssistant, key, value, "preferences.cache_entities: false", "cache_timeout", "homeassistant.cache_timeout"))
+		} else {
+			changes = append(changes, "removed preferences.cache_entities: caching is on unless homeassistant.cache_timeout is 0")
+		}
+	}
+	if key, value := takeKey(preferences, "color_output"); key != nil {
+		changes = append(changes, moveKey(section(document, "output"), key, value, "preferences.color_output", "color", "output.color"))
+	}
+	if key, value := takeKey(preferences, "verbosity"); key != nil {
+		changes = append(changes, moveKey(section(document, "output"), key, value, "preferences.verbosity", "verbosity", "output.verbosity"))
+	}
+	if key, value := takeKey(preferences, "verbose_output"); key != nil {
+		if verbose, err := strconv.ParseBool(value.Value); err == nil && verbose {
+			value.Value = "2"
+			value.Tag = "!!int"
+			changes = append(changes, moveKey(section(document, "output"), key, value, "preferences.verbose_output: true", "verbosity", "output.verbosity"))
+		} else {
+			changes = append(changes, "removed preferences.verbose_output: use output.verbosity instead")
+		}
+	}
+
+	if len(preferences.Content) == 0 {
+		takeKey(document, "preferences")
+	}
+
+	return changes
+}
+
+// moveKey adds key to mapping under a new name unless it is already set
+// there, in which case the old value is dropped.
+func moveKey(mapping, key, value *yaml.Node, from, name, to string) string {
+	if existing := mappingValue(mapping, name); existing != nil {
+		return fmt.Sprintf("removed %s: %s is already set", from, to)
+	}
+
+	key.Value = name
+	mapping.Content = append(mapping.Content, key, value)
+	return fmt.Sprintf("%s → %s: %s", from, to, value.Value)
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// takeKey removes key from mapping and returns its key and value nodes.
+func takeKey(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			keyNode, valueNode := mapping.Content[i], mapping.Content[i+1]
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return keyNode, valueNode
+		}
+	}
+	return nil, nil
+}
+
+// section returns the top-level mapping name, adding it when missing.
+func section(document *yaml.Node, name string) *yaml.Node {
+	if value := mappingValue(document, name); value != nil && value.Kind == yaml.MappingNode {
+		return value
+	}
+
+	takeKey(document, name)
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	document.Content = append(document.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+	return value
+}
+
+// rewriteConfig copies the original file to a timestamped backup, then
+// atomically replaces it with data.
+func rewriteConfig(path string, original, data []byte) (string, error) {
+	perms := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		perms = info.Mode().Perm()
+	}
+
+	backup := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, original, 0600); err != nil {
+		return "", fmt.Errorf("failed to write config backup: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), perms); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return backup, nil
+}
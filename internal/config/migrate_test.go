@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const specLayout = `# Home Assistant connection settings
+homeassistant:
+  url: "http://homeassistant.local:8123"
+  verify_ssl: false
+
+preferences:
+  cache_ttl: "10m"
+  verbosity: 2
+  color_output: false
+
+output:
+  format: "pretty"
+`
+
+func TestMigrateSpecLayout(t *testing.T) {
+	migrated, report, err := Migrate([]byte(specLayout))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report == nil || report.From != 0 || report.To != CurrentVersion {
+		t.Fatalf("expected a migration from 0 to %d, got %+v", CurrentVersion, report)
+	}
+	if len(report.Changes) != 5 {
+		t.Errorf("expected 5 changes, got %q", report.Changes)
+	}
+	if !strings.Contains(string(migrated), "# Home Assistant connection settings") {
+		t.Error("expected comments to be kept")
+	}
+
+	cfg, problems := Parse(migrated)
+	if len(problems) != 0 {
+		t.Fatalf("expected migrated config to be clean, got %v", problems)
+	}
+
+	if cfg.Version != CurrentVersion {
+		t.Errorf("expected version %d, got %d", CurrentVersion, cfg.Version)
+	}
+	if !cfg.HomeAssistant.SkipTLSVerify {
+		t.Error("expected verify_ssl: false to become skip_tls_verify: true")
+	}
+	if cfg.HomeAssistant.CacheTimeout != 10*time.Minute {
+		t.Errorf("expected cache_timeout 10m, got %s", cfg.HomeAssistant.CacheTimeout)
+	}
+	if cfg.Output.Verbosity != 2 || cfg.Output.Color || cfg.Output.Format != "text" {
+		t.Errorf("unexpected output config %+v", cfg.Output)
+	}
+
+	if _, report, _ := Migrate(migrated); report != nil {
+		t.Errorf("expected migrated config to be current, got %+v", report)
+	}
+}
+
+func TestMigrateKeepsExistingValues(t *testing.T) {
+	data := "homeassistant:\n  cache_timeout: 1m\npreferences:\n  cache_ttl: 10m\n"
+
+	migrated, report, err := Migrate([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Changes) != 1 || !strings.Contains(report.Changes[0], "already set") {
+		t.Errorf("expected the legacy key to be dropped, got %q", report.Changes)
+	}
+
+	cfg, _ := Parse(migrated)
+	if cfg.HomeAssistant.CacheTimeout != time.Minute {
+		t.Errorf("expected cache_timeout to stay 1m, got %s", cfg.HomeAssistant.CacheTimeout)
+	}
+}
+
+func TestParseMigratesInMemory(t *testing.T) {
+	cfg, problems := Parse([]byte(specLayout))
+	if len(problems) != 0 {
+		t.Fatalf("expected no problems for the spec layout, got %v", problems)
+	}
+	if !cfg.HomeAssistant.SkipTLSVerify {
+		t.Error("expected Parse to apply migrations")
+	}
+}
+
+func TestParseNewerVersion(t *testing.T) {
+	_, problems := Parse([]byte("version: 99\n"))
+	if !HasErrors(problems) || problems[0].Line != 1 {
+		t.Errorf("expected an error on line 1, got %v", problems)
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(specLayout), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := MigrateFile(path, true)
+	if err != nil || report == nil || report.Backup != "" {
+		t.Fatalf("expected a dry run report, got %+v (err=%v)", report, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != specLayout {
+		t.Fatal("expected dry run to leave the file alone")
+	}
+
+	report, err = MigrateFile(path, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backup, err := os.ReadFile(report.Backup); err != nil || string(backup) != specLayout {
+		t.Errorf("expected backup to hold the original, got %q (err=%v)", backup, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "version: 1") {
+		t.Errorf("expected rewritten file to start with the version, got:\n%s", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions to be kept, got %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
+)
+
+// Token store backends selectable with security.token_store.
+const (
+	TokenStoreConfig  = "config"
+	TokenStoreKeyring = "keyring"
+	TokenStoreFile    = "file"
+	TokenStoreCommand = "command"
+)
+
+const keyringUser = "token"
+
+var (
+	ErrTokenNotFound = errors.New("no token stored")
+	ErrReadOnlyStore = errors.New("token store is read-only")
+)
+
+// PassphraseFunc supplies the passphrase for the encrypted token file.
+// confirm is true when a new file is being written.
+var PassphraseFunc = readPassphrase
+
+// TokenStore keeps the Home Assistant access token outside config.yaml.
+type TokenStore interface {
+	Name() string
+	Get() (string, error)
+	Set(token string) error
+	Delete() error
+}
+
+// TokenStoreName returns the backend the token is read from.
+func (c *Config) TokenStoreName() string {
+	switch {
+	case c.Security.TokenStore != "":
+		return c.Security.TokenStore
+	case c.Security.TokenCommand != "":
+		return TokenStoreCommand
+	case c.Security.UseKeyring:
+		return TokenStoreKeyring
+	default:
+		return TokenStoreConfig
+	}
+}
+
+// NewTokenStore returns the store for name; TokenStoreConfig has none.
+func (c *Config) NewTokenStore(name string) (TokenStore, error) {
+	switch name {
+	case TokenStoreKeyring:
+		service := c.Security.KeyringService
+		if service == "" {
+			service = "hass-cli"
+		}
+		return &keyringStore{service: service}, nil
+	case TokenStoreFile:
+		path := c.Security.TokenFile
+		if path == "" {
+			configPath, err := getConfigPath()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get config path: %w", err)
+			}
+			path = filepath.Join(filepath.Dir(configPath), "token.age")
+		}
+		return &fileStore{path: path}, nil
+	case TokenStoreCommand:
+		if c.Security.TokenCommand == "" {
+			return nil, fmt.Errorf("security.token_command is not set")
+		}
+		return &commandStore{command: c.Security.TokenCommand}, nil
+	default:
+		return nil, fmt.Errorf("unknown token store %q (expected keyring, file or command)", name)
+	}
+}
+
+// loadToken fills in the token from its store when config.yaml has none.
+// Keyring lookups that were not explicitly requested fail silently, since
+// many headless machines have no keyring at all.
+func (c *Config) loadToken() error {
+	name := c.TokenStoreName()
+	if c.HomeAssistant.Token != "" || name == TokenStoreConfig {
+		return nil
+	}
+
+	store, err := c.NewTokenStore(name)
+	if err != nil {
+		return err
+	}
+
+	token, err := store.Get()
+	switch {
+	case errors.Is(err, ErrTokenNotFound):
+		return nil
+	case err != nil && c.Security.TokenStore == "" && name == TokenStoreKeyring:
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to read token from %s: %w", store.Name(), err)
+	}
+
+	c.HomeAssistant.Token = token
+	c.tokenFromStore = true
+	return nil
+}
+
+type keyringStore struct {
+	service string
+}
+
+func (s *keyringStore) Name() string {
+	return "keyring"
+}
+
+func (s *keyringStore) Get() (string, error) {
+	token, err := keyring.Get(s.service, keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrTokenNotFound
+	}
+	return token, err
+}
+
+func (s *keyringStore) Set(token string) error {
+	return keyring.Set(s.service, keyringUser, token)
+}
+
+func (s *keyringStore) Delete() error {
+	err := keyring.Delete(s.service, keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// fileStore keeps the token in an age file encrypted with a passphrase.
+type fileStore struct {
+	path string
+}
+
+func (s *fileStore) Name() string {
+	return "encrypted file " + s.path
+}
+
+func (s *fileStore) Get() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	passphrase, err := PassphraseFunc(false)
+	if err != nil {
+		return "", err
+	}
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	reader, err := age.Decrypt(bytes.NewReader(data), identity)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token (wrong passphrase?): %w", err)
+	}
+
+	token, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+func (s *fileStore) Set(token string) error {
+	passphrase, err := PassphraseFunc(true)
+	if err != nil {
+		return err
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+
+	var encrypted bytes.Buffer
+	writer, err := age.Encrypt(&encrypted, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(writer, token); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, encrypted.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *fileStore) Delete() error {
+	err := os.Remove(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// commandStore runs a command such as "pass show ha/token" and uses its
+// output as the token.
+type commandStore struct {
+	command string
+}
+
+func (s *commandStore) Name() string {
+	return "token_command"
+}
+
+func (s *commandStore) Get() (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", s.command)
+	} else {
+		cmd = exec.Command("sh", "-c", s.command)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(output))
+	if token == "" {
+		return "", ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *commandStore) Set(string) error {
+	return ErrReadOnlyStore
+}
+
+func (s *commandStore) Delete() error {
+	return ErrReadOnlyStore
+}
+
+// readPassphrase takes the passphrase from HASS_TOKEN_PASSPHRASE, or asks for
+// it on the terminal.
+func readPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv("HASS_TOKEN_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("token file is encrypted; set HASS_TOKEN_PASSPHRASE or run from a terminal")
+	}
+
+	passphrase, err := promptSecret(fd, "Token passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+
+	if confirm {
+		again, err := promptSecret(fd, "Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+func promptSecret(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(secret), nil
+}
+
+// TokenFromStore reports whether the token was read from a token store
+// rather than config.yaml.
+func (c *Config) TokenFromStore() bool {
+	return c.tokenFromStore
+}
+
+// StoreToken saves token in the named store and removes any plaintext copy
+// from the config, which the caller must Save.
+func (c *Config) StoreToken(name, token string) (TokenStore, error) {
+	store, err := c.NewTokenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Set(token); err != nil {
+		return nil, fmt.Errorf("failed to write token to %s: %w", store.Name(), err)
+	}
+
+	c.Security.TokenStore = name
+	c.HomeAssistant.Token = token
+	c.tokenFromStore = true
+	return store, nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	t.Setenv("HASS_TOKEN_PASSPHRASE", "correct horse")
+
+	cfg := DefaultConfig()
+	cfg.Security.TokenFile = filepath.Join(t.TempDir(), "token.age")
+
+	store, err := cfg.NewTokenStore(TokenStoreFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := store.Get(); err != ErrTokenNotFound {
+		t.Fatalf("expected ErrTokenNotFound before set, got %v", err)
+	}
+
+	if err := store.Set("secret-token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(cfg.Security.TokenFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("expected token file to be encrypted")
+	}
+
+	if token, err := store.Get(); err != nil || token != "secret-token" {
+		t.Errorf("expected secret-token, got %q (err=%v)", token, err)
+	}
+
+	t.Setenv("HASS_TOKEN_PASSPHRASE", "wrong")
+	if _, err := store.Get(); err == nil {
+		t.Error("expected wrong passphrase to fail")
+	}
+}
+
+func TestCommandTokenStore(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Security.TokenCommand = "echo from-command"
+
+	if err := cfg.loadToken(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HomeAssistant.Token != "from-command" || !cfg.TokenFromStore() {
+		t.Errorf("expected token from command, got %q", cfg.HomeAssistant.Token)
+	}
+
+	store, _ := cfg.NewTokenStore(TokenStoreCommand)
+	if err := store.Set("x"); err != ErrReadOnlyStore {
+		t.Errorf("expected ErrReadOnlyStore, got %v", err)
+	}
+}
+
+func TestKeyringTokenStore(t *testing.T) {
+	keyring.MockInit()
+
+	cfg := DefaultConfig()
+	if _, err := cfg.StoreToken(TokenStoreKeyring, "keyring-token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	loaded.Security.TokenStore = cfg.Security.TokenStore
+	if err := loaded.loadToken(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.HomeAssistant.Token != "keyring-token" {
+		t.Errorf("expected keyring-token, got %q", loaded.HomeAssistant.Token)
+	}
+}
+
+func TestSaveOmitsStoredToken(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	cfg := DefaultConfig()
+	cfg.HomeAssistant.Token = "plaintext-token"
+	if _, err := cfg.StoreToken(TokenStoreKeyring, cfg.HomeAssistant.Token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path, _ := getConfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "plaintext-token") {
+		t.Error("expected Save to leave the stored token out of config.yaml")
+	}
+	if !strings.Contains(string(data), "token_store: keyring") {
+		t.Errorf("expected token_store to be saved, got:\n%s", data)
+	}
+}
+
+func TestLoadWithoutTokenSkipsStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	path, _ := getConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("security:\n  token_command: exit 1\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected Load to fail on the broken token_command")
+	}
+	cfg, err := LoadWithoutToken()
+	if err != nil {
+		t.Fatalf("expected LoadWithoutToken to skip the token store, got %v", err)
+	}
+	if cfg.Security.TokenCommand != "exit 1" || cfg.HomeAssistant.Token != "" {
+		t.Errorf("unexpected config: %+v", cfg.Security)
+	}
+}
@@ -0,0 +1,348 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var yamlLinePattern = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// Problem is one issue found while validating a config file. Line and Column
+// are zero when the problem is not tied to a position, such as a default
+// value that fails a check.
+type Problem struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+	Warning bool
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	switch {
+	case p.Line > 0 && p.Column > 0:
+		fmt.Fprintf(&b, "line %d, column %d: ", p.Line, p.Column)
+	case p.Line > 0:
+		fmt.Fprintf(&b, "line %d: ", p.Line)
+	}
+	if p.Path != "" {
+		b.WriteString(p.Path + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// ValidationError lists every error found in a config file.
+type ValidationError struct {
+	File     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration in %s:", e.File)
+	for _, problem := range e.Problems {
+		b.WriteString("\n  " + problem.String())
+	}
+	return b.String()
+}
+
+// Path returns the location of config.yaml.
+func Path() (string, error) {
+	return getConfigPath()
+}
+
+// Warnings returns the non-fatal problems found when the config was loaded,
+// such as unknown fields.
+func (c *Config) Warnings() []Problem {
+	return c.warnings
+}
+
+// Parse decodes config YAML over the defaults and validates it. The config is
+// returned even when there are problems so callers can report all of them.
+// !secret and !include are resolved relative to the default config directory.
+func Parse(data []byte) (*Config, []Problem) {
+	path, err := getConfigPath()
+	if err != nil {
+		path = "config.yaml"
+	}
+	return parse(data, path)
+}
+
+// ParseFile reads and parses the config file at path.
+func ParseFile(path string) (*Config, []Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, problems := parse(data, path)
+	return cfg, problems, nil
+}
+
+func parse(data []byte, path string) (*Config, []Problem) {
+	cfg := DefaultConfig()
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return cfg, []Problem{syntaxProblem(err)}
+	}
+	if len(root.Content) == 0 {
+		return cfg, nil
+	}
+	document := root.Content[0]
+
+	// Older layouts are checked as they will be after migration. A bad
+	// version field is reported by Decode below.
+	_, _ = migrateDocument(document)
+
+	var problems []Problem
+	tags := newResolver(path)
+	tags.resolve(document, tags.dir, &problems)
+	cfg.resolvedTags = tags.used
+	cfg.sources = tags.files
+
+	positions := make(map[string]*yaml.Node)
+	checkFields(document, reflect.TypeOf(Config{}), "", positions, &problems)
+
+	if err := document.Decode(cfg); err != nil {
+		problems = append(problems, decodeProblems(err)...)
+	}
+
+	for _, problem := range cfg.checkValues() {
+		if node, ok := positions[problem.Path]; ok {
+			problem.Line, problem.Column = node.Line, node.Column
+		}
+		problems = append(problems, problem)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return cfg, problems
+}
+
+// HasErrors reports whether any problem is more than a warning.
+func HasErrors(problems []Problem) bool {
+	for _, problem := range problems {
+		if !problem.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks values that YAML decoding alone cannot catch.
+func (c *Config) Validate() error {
+	problems := c.checkValues()
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{File: "config", Problems: problems}
+}
+
+func (c *Config) checkValues() []Problem {
+	var problems []Problem
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if raw := c.HomeAssistant.URL; raw != "" {
+		parsed, err := url.Parse(raw)
+		switch {
+		case err != nil:
+			add("homeassistant.url", "not a valid URL: %v", err)
+		case parsed.Scheme != "http" && parsed.Scheme != "https":
+			add("homeassistant.url", "must start with http:// or https://, got %q", raw)
+		case parsed.Host == "":
+			add("homeassistant.url", "is missing a host name")
+		}
+	}
+
+	if c.Version > CurrentVersion {
+		add("version", "was written by a newer hass-cli (version %d); this build supports up to version %d", c.Version, CurrentVersion)
+	}
+
+	if c.HomeAssistant.Timeout <= 0 {
+		add("homeassistant.timeout", "must be greater than zero, got %s", c.HomeAssistant.Timeout)
+	}
+	if c.HomeAssistant.CacheTimeout < 0 {
+		add("homeassistant.cache_timeout", "must not be negative, got %s", c.HomeAssistant.CacheTimeout)
+	}
+	if c.HomeAssistant.Retries < 0 || c.HomeAssistant.Retries > 10 {
+		add("homeassistant.retries", "must be between 0 and 10, got %d", c.HomeAssistant.Retries)
+	}
+	if c.HomeAssistant.RetryBackoff < 0 {
+		add("homeassistant.retry_backoff", "must not be negative, got %s", c.HomeAssistant.RetryBackoff)
+	}
+
+	if threshold := c.Preferences.FuzzyThreshold; threshold < 0 || threshold > 1 {
+		add("preferences.fuzzy_threshold", "must be between 0 and 1, got %v", threshold)
+	}
+
+	for _, rule := range c.Preferences.ConfirmDestructive {
+		scope, service, _ := strings.Cut(rule, ".")
+		if scope == "" || strings.ContainsAny(rule, " \t") || strings.Contains(service, ".") || strings.HasSuffix(rule, ".") {
+			add("preferences.confirm_destructive", "%q must be a domain or domain.service, such as lock.unlock", rule)
+		}
+	}
+
+	switch c.Output.Format {
+	case "text", "json", "yaml":
+	default:
+		add("output.format", "must be text, json or yaml, got %q", c.Output.Format)
+	}
+	if c.Output.Verbosity < 0 || c.Output.Verbosity > 2 {
+		add("output.verbosity", "must be 0, 1 or 2, got %d", c.Output.Verbosity)
+	}
+
+	if c.Discovery.Timeout < 0 {
+		add("discovery.timeout", "must not be negative, got %s", c.Discovery.Timeout)
+	}
+	for _, port := range c.Discovery.CustomPorts {
+		if port < 1 || port > 65535 {
+			add("discovery.custom_ports", "port %d is out of range", port)
+		}
+	}
+
+	switch c.Security.TokenStore {
+	case "", TokenStoreConfig, TokenStoreKeyring, TokenStoreFile, TokenStoreCommand:
+	default:
+		add("security.token_store", "must be config, keyring, file or command, got %q", c.Security.TokenStore)
+	}
+	if c.Security.TokenStore == TokenStoreCommand && c.Security.TokenCommand == "" {
+		add("security.token_command", "is required when token_store is command")
+	}
+
+	if !slices.Contains(TUIThemes, c.TUI.Theme) {
+		add("tui.theme", "must be one of %s, got %q", strings.Join(TUIThemes, ", "), c.TUI.Theme)
+	}
+	for action, keys := range c.TUI.Keybindings {
+		switch {
+		case !slices.Contains(TUIActions, action):
+			add("tui.keybindings", "unknown action %q (actions: %s)", action, strings.Join(TUIActions, ", "))
+		case strings.TrimSpace(keys) == "":
+			add("tui.keybindings."+action, "needs at least one key")
+		}
+	}
+
+	if err := c.ValidateMacros(); err != nil {
+		add("macros", "%v", err)
+	}
+
+	return problems
+}
+
+// checkFields warns about keys that match no field of t, recording the node
+// of every known key by its dotted path.
+func checkFields(node *yaml.Node, t reflect.Type, path string, positions map[string]*yaml.Node, problems *[]Problem) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if t.Kind() != reflect.Struct || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = field
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldPath := key.Value
+		if path != "" {
+			fieldPath = path + "." + key.Value
+		}
+
+		field, ok := fields[key.Value]
+		if !ok {
+			message := fmt.Sprintf("unknown field %q", key.Value)
+			if suggestion := closestField(key.Value, fields); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			*problems = append(*problems, Problem{
+				Line:    key.Line,
+				Column:  key.Column,
+				Path:    path,
+				Message: message,
+				Warning: true,
+			})
+			continue
+		}
+
+		positions[fieldPath] = value
+		checkFields(value, field.Type, fieldPath, positions, problems)
+	}
+}
+
+func closestField(name string, fields map[string]reflect.StructField) string {
+	best, bestDistance := "", 3
+	for candidate := range fields {
+		distance := editDistance(name, candidate)
+		if distance < bestDistance || (distance == bestDistance && best != "" && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func syntaxProblem(err error) Problem {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	match := yamlLinePattern.FindStringSubmatch(message)
+	if match == nil {
+		return Problem{Message: message}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	return Problem{Line: line, Message: match[2]}
+}
+
+func decodeProblems(err error) []Problem {
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return []Problem{syntaxProblem(err)}
+	}
+
+	problems := make([]Problem, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		problems = append(problems, syntaxProblem(fmt.Errorf("%s", message)))
+	}
+	return problems
+}
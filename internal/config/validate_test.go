@@ -0,0 +1,114 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		line    int
+		path    string
+		message string
+		warning bool
+	}{
+		{
+			name:    "unknown field with suggestion",
+			yaml:    "homeassistant:\n  url: http://localhost:8123\n  timout: 5s\n",
+			line:    3,
+			path:    "homeassistant",
+			message: `unknown field "timout" (did you mean "timeout"?)`,
+			warning: true,
+		},
+		{
+			name:    "negative timeout",
+			yaml:    "homeassistant:\n  url: http://localhost:8123\n  timeout: -5s\n",
+			line:    3,
+			path:    "homeassistant.timeout",
+			message: "must be greater than zero",
+		},
+		{
+			name:    "fuzzy threshold out of range",
+			yaml:    "preferences:\n  fuzzy_threshold: 7\n",
+			line:    2,
+			path:    "preferences.fuzzy_threshold",
+			message: "must be between 0 and 1",
+		},
+		{
+			name:    "url without scheme",
+			yaml:    "homeassistant:\n  url: homeassistant.local:8123\n",
+			line:    2,
+			path:    "homeassistant.url",
+			message: "http://",
+		},
+		{
+			name:    "syntax error",
+			yaml:    "homeassistant:\n  url: http://localhost:8123\n\tbad: indent\n",
+			line:    2,
+			message: "tab character",
+		},
+		{
+			name:    "wrong type",
+			yaml:    "output:\n  verbosity: loud\n",
+			line:    2,
+			message: "cannot unmarshal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := Parse([]byte(tt.yaml))
+			if len(problems) != 1 {
+				t.Fatalf("expected one problem, got %v", problems)
+			}
+
+			problem := problems[0]
+			if problem.Line != tt.line {
+				t.Errorf("expected line %d, got %d (%s)", tt.line, problem.Line, problem)
+			}
+			if problem.Path != tt.path {
+				t.Errorf("expected path %q, got %q", tt.path, problem.Path)
+			}
+			if !strings.Contains(problem.Message, tt.message) {
+				t.Errorf("expected message containing %q, got %q", tt.message, problem.Message)
+			}
+			if problem.Warning != tt.warning {
+				t.Errorf("expected warning=%v, got %v", tt.warning, problem.Warning)
+			}
+		})
+	}
+}
+
+func TestParseValidConfig(t *testing.T) {
+	data := "homeassistant:\n  url: https://ha.example.com\n  timeout: 10s\noutput:\n  format: json\n"
+
+	cfg, problems := Parse([]byte(data))
+	if len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+	if cfg.Output.Format != "json" {
+		t.Errorf("expected format json, got %s", cfg.Output.Format)
+	}
+}
+
+func TestParseOutputFormats(t *testing.T) {
+	for _, format := range []string{"text", "json", "yaml", "pretty"} {
+		_, problems := Parse([]byte("output:\n  format: " + format + "\n"))
+		if len(problems) != 0 {
+			t.Errorf("expected format %s to be accepted, got %v", format, problems)
+		}
+	}
+
+	_, problems := Parse([]byte("output:\n  format: xml\n"))
+	if len(problems) != 1 || problems[0].Path != "output.format" {
+		t.Errorf("expected format xml to be rejected, got %v", problems)
+	}
+}
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("expected defaults to validate, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// reloadDelay lets editors finish writing before the file is read.
+	reloadDelay = 100 * time.Millisecond
+	// pollInterval is how often files are checked when fsnotify is
+	// unavailable.
+	pollInterval = 2 * time.Second
+)
+
+// Reload copies the settings that can change while hass is running from
+// next. It returns the changed settings that only take effect on restart.
+func (c *Config) Reload(next *Config) []string {
+	c.Aliases = next.Aliases
+	c.Macros = next.Macros
+	c.Preferences = next.Preferences
+	c.Output = next.Output
+	c.TUI = next.TUI
+	c.warnings = next.warnings
+	c.sources = next.sources
+
+	var restart []string
+	if !reflect.DeepEqual(c.HomeAssistant, next.HomeAssistant) {
+		restart = append(restart, "homeassistant")
+	}
+	if !reflect.DeepEqual(c.Discovery, next.Discovery) {
+		restart = append(restart, "discovery")
+	}
+	if !reflect.DeepEqual(c.Security, next.Security) {
+		restart = append(restart, "security")
+	}
+	return restart
+}
+
+// Watch reloads config.yaml whenever it, secrets.yaml or an included file
+// changes, until ctx is done. onChange receives the new config, or the reason
+// it could not be loaded, in which case the current config should be kept.
+// It uses filesystem notifications and falls back to polling.
+func Watch(ctx context.Context, cfg *Config, onChange func(*Config, error)) {
+	path, err := getConfigPath()
+	if err != nil {
+		onChange(nil, fmt.Errorf("failed to get config path: %w", err))
+		return
+	}
+
+	w := newWatcher(path, cfg, onChange)
+	if err := w.notify(ctx); err != nil {
+		w.poll(ctx)
+	}
+}
+
+type watcher struct {
+	path     string
+	sources  []string
+	onChange func(*Config, error)
+	last     string
+
+	// token and tokenFromStore are carried over to reloaded configs that
+	// take their token from a store, which is only read at startup.
+	token          string
+	tokenFromStore bool
+}
+
+func newWatcher(path string, current *Config, onChange func(*Config, error)) *watcher {
+	w := &watcher{path: path, onChange: onChange}
+	var sources []string
+	if current != nil {
+		sources = current.sources
+		w.token, w.tokenFromStore = current.HomeAssistant.Token, current.tokenFromStore
+	}
+	w.setSources(sources)
+	w.last = w.fingerprint()
+	return w
+}
+
+func (w *watcher) setSources(sources []string) {
+	w.sources = w.sources[:0]
+	for _, source := range append([]string{w.path}, sources...) {
+		if abs, err := filepath.Abs(source); err == nil {
+			source = abs
+		}
+		if !slices.Contains(w.sources, source) {
+			w.sources = append(w.sources, source)
+		}
+	}
+}
+
+// fingerprint summarises the size and modification time of every source.
+func (w *watcher) fingerprint() string {
+	var fingerprint string
+	for _, source := range w.sources {
+		if info, err := os.Stat(source); err == nil {
+			fingerprint += fmt.Sprintf("%s:%d:%d;", source, info.Size(), info.ModTime().UnixNano())
+		} else {
+			fingerprint += source + ":missing;"
+		}
+	}
+	return fingerprint
+}
+
+// check reloads the config if any source changed since the last check.
+func (w *watcher) check() {
+	fingerprint := w.fingerprint()
+	if fingerprint == w.last {
+		return
+	}
+	w.last = fingerprint
+
+	if _, err := os.Stat(w.path); errors.Is(err, os.ErrNotExist) {
+		w.onChange(nil, fmt.Errorf("%s was removed; keeping the current settings", w.path))
+		return
+	}
+
+	cfg, err := readFile(w.path, false)
+	if err != nil {
+		w.onChange(nil, err)
+		return
+	}
+	if cfg.HomeAssistant.Token == "" && w.tokenFromStore {
+		cfg.HomeAssistant.Token, cfg.tokenFromStore = w.token, true
+	}
+
+	w.setSources(cfg.sources)
+	w.last = w.fingerprint()
+	w.onChange(cfg, nil)
+}
+
+func (w *watcher) notify(ctx context.Context) error {
+	notifier, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer notifier.Close()
+
+	// Directories are watched because editors often replace files by renaming.
+	watched := make(map[string]bool)
+	watchDirs := func() error {
+		for _, source := range w.sources {
+			dir := filepath.Dir(source)
+			if watched[dir] {
+				continue
+			}
+			if err := notifier.Add(dir); err != nil {
+				return err
+			}
+			watched[dir] = true
+		}
+		return nil
+	}
+	if err := watchDirs(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-notifier.Events:
+			if !ok {
+				return nil
+			}
+			if slices.Contains(w.sources, event.Name) {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-notifier.Errors:
+			if !ok {
+				return nil
+			}
+			w.onChange(nil, fmt.Errorf("failed to watch config: %w", err))
+		case <-timer.C:
+			w.check()
+			// Newly included files may live in other directories.
+			if err := watchDirs(); err != nil {
+				w.onChange(nil, fmt.Errorf("failed to watch config: %w", err))
+			}
+		}
+	}
+}
+
+func (w *watcher) poll(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
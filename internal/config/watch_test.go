@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestReload(t *testing.T) {
+	current := DefaultConfig()
+	current.HomeAssistant.URL = "http://old:8123"
+
+	next := DefaultConfig()
+	next.HomeAssistant.URL = "http://new:8123"
+	next.Aliases = map[string]string{"lr": "living room"}
+	next.Preferences.FuzzyThreshold = 0.8
+	next.TUI.Theme = "mono"
+
+	restart := current.Reload(next)
+
+	if current.Aliases["lr"] != "living room" || current.Preferences.FuzzyThreshold != 0.8 || current.TUI.Theme != "mono" {
+		t.Errorf("expected live settings to be swapped, got %+v", current)
+	}
+	if current.HomeAssistant.URL != "http://old:8123" {
+		t.Errorf("expected the connection to be kept, got %s", current.HomeAssistant.URL)
+	}
+	if len(restart) != 1 || restart[0] != "homeassistant" {
+		t.Errorf("expected homeassistant to need a restart, got %v", restart)
+	}
+}
+
+func TestWatcherReloadsOnChange(t *testing.T) {
+	keyring.MockInit()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("aliases:\n  lr: living room\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	changes := make(chan *Config, 1)
+	errs := make(chan error, 1)
+	w := newWatcher(path, nil, func(cfg *Config, err error) {
+		if err != nil {
+			errs <- err
+			return
+		}
+		changes <- cfg
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = w.notify(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(path, []byte("aliases:\n  br: bedroom\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case cfg := <-changes:
+		if cfg.Aliases["br"] != "bedroom" {
+			t.Errorf("expected the new aliases, got %v", cfg.Aliases)
+		}
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reload after the file changed")
+	}
+}
+
+func TestWatcherReportsInvalidEdit(t *testing.T) {
+	keyring.MockInit()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("output:\n  verbosity: 1\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got error
+	w := newWatcher(path, nil, func(cfg *Config, err error) {
+		got = err
+	})
+
+	if err := os.WriteFile(path, []byte("output:\n  verbosity: 10\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.check()
+
+	if got == nil || !strings.Contains(got.Error(), "output.verbosity") {
+		t.Errorf("expected a validation error, got %v", got)
+	}
+
+	// An unchanged file is not reported again.
+	got = nil
+	w.check()
+	if got != nil {
+		t.Errorf("expected no second report, got %v", got)
+	}
+}
+
+func TestWatcherKeepsStoredToken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	runs := filepath.Join(dir, "runs")
+	config := "security:\n  token_command: echo run >> " + runs + "; echo from-command\n"
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current, err := loadFile(path, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var next *Config
+	w := newWatcher(path, current, func(cfg *Config, err error) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		next = cfg
+	})
+
+	if err := os.WriteFile(path, []byte(config+"aliases:\n  lr: living room\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.check()
+
+	if next == nil {
+		t.Fatal("expected a reload after the file changed")
+	}
+	if next.HomeAssistant.Token != "from-command" {
+		t.Errorf("expected the current token to be kept, got %q", next.HomeAssistant.Token)
+	}
+	if restart := current.Reload(next); len(restart) != 0 {
+		t.Errorf("expected nothing to need a restart, got %v", restart)
+	}
+	if data, _ := os.ReadFile(runs); strings.Count(string(data), "run") != 1 {
+		t.Errorf("expected token_command to run once, got %q", data)
+	}
+}
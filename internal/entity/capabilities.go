@@ -0,0 +1,583 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+// Bits of the supported_features attribute, as defined by Home Assistant.
+const (
+	LightFeatureEffect     = 4
+	LightFeatureFlash      = 8
+	LightFeatureTransition = 32
+
+	FanFeatureSetSpeed   = 1
+	FanFeatureOscillate  = 2
+	FanFeatureDirection  = 4
+	FanFeaturePresetMode = 8
+
+	ClimateFeatureTargetTemperature      = 1
+	ClimateFeatureTargetTemperatureRange = 2
+	ClimateFeatureTargetHumidity         = 4
+	ClimateFeatureFanMode                = 8
+	ClimateFeaturePresetMode             = 16
+	ClimateFeatureSwingMode              = 32
+
+	CoverFeatureOpen            = 1
+	CoverFeatureClose           = 2
+	CoverFeatureSetPosition     = 4
+	CoverFeatureStop            = 8
+	CoverFeatureOpenTilt        = 16
+	CoverFeatureCloseTilt       = 32
+	CoverFeatureStopTilt        = 64
+	CoverFeatureSetTiltPosition = 128
+
+	LockFeatureOpen = 1
+
+	AlarmFeatureArmHome  = 1
+	AlarmFeatureArmAway  = 2
+	AlarmFeatureArmNight = 4
+
+	MediaFeaturePause           = 1
+	MediaFeatureVolumeSet       = 4
+	MediaFeatureVolumeMute      = 8
+	MediaFeaturePreviousTrack   = 16
+	MediaFeatureNextTrack       = 32
+	MediaFeaturePlayMedia       = 512
+	MediaFeatureVolumeStep      = 1024
+	MediaFeatureSelectSource    = 2048
+	MediaFeatureStop            = 4096
+	MediaFeaturePlay            = 16384
+	MediaFeatureShuffleSet      = 32768
+	MediaFeatureSelectSoundMode = 65536
+	MediaFeatureRepeatSet       = 262144
+	MediaFeatureGrouping        = 524288
+
+	VacuumFeaturePause       = 4
+	VacuumFeatureStop        = 8
+	VacuumFeatureReturnHome  = 16
+	VacuumFeatureFanSpeed    = 32
+	VacuumFeatureSendCommand = 256
+	VacuumFeatureLocate      = 512
+	VacuumFeatureCleanSpot   = 1024
+	VacuumFeatureStart       = 8192
+
+	LawnMowerFeatureStartMowing = 1
+	LawnMowerFeaturePause       = 2
+	LawnMowerFeatureDock        = 4
+
+	WaterHeaterFeatureTargetTemperature = 1
+	WaterHeaterFeatureOperationMode     = 2
+	WaterHeaterFeatureAwayMode          = 4
+	WaterHeaterFeatureOnOff             = 8
+)
+
+var ErrNotSupported = errors.New("action not supported by entity")
+
+// NotSupportedError explains why an entity cannot perform an action and
+// what it can do instead.
+type NotSupportedError struct {
+	EntityID  string
+	Action    string
+	Reason    string
+	Supported []string
+}
+
+func (e *NotSupportedError) Error() string {
+	message := fmt.Sprintf("%s does not support %s", e.EntityID, e.Action)
+	if e.Reason != "" {
+		message += ": " + e.Reason
+	}
+	if len(e.Supported) > 0 {
+		message += fmt.Sprintf(" (supported: %s)", strings.Join(e.Supported, ", "))
+	}
+	return message
+}
+
+func (e *NotSupportedError) Unwrap() error {
+	return ErrNotSupported
+}
+
+// Capabilities describes what an entity can do, decoded from its
+// supported_features bitmask and domain-specific attributes.
+type Capabilities struct {
+	EntityID string
+	Domain   string
+	// Known is false when the entity reports no supported_features, as with
+	// some older integrations; checks then allow everything.
+	Known    bool
+	Features int
+
+	// Lights
+	ColorModes []string
+	Effects    []string
+	MinKelvin  float64
+	MaxKelvin  float64
+
+	// Fans
+	PercentageStep float64
+
+	// Climate
+	HVACModes   []string
+	FanModes    []string
+	SwingModes  []string
+	MinTemp     float64
+	MaxTemp     float64
+	TempStep    float64
+	MinHumidity float64
+	MaxHumidity float64
+
+	// Shared by fans and climate
+	PresetModes []string
+
+	// Media players
+	Sources    []string
+	SoundModes []string
+
+	// Vacuums. SegmentCleaning is set for Xiaomi and Roborock vacuums, the
+	// only ones known to accept the app_segment_clean command.
+	FanSpeeds       []string
+	SegmentCleaning bool
+
+	// Water heaters
+	OperationModes []string
+
+	// Helpers and number, select and text entities: min and max bound
+	// numbers and counters, and the length of text
+	Min     float64
+	Max     float64
+	Step    float64
+	Options []string
+	Pattern string
+	HasDate bool
+	HasTime bool
+}
+
+// CapabilitiesOf decodes the capabilities of state.
+func CapabilitiesOf(state client.EntityState) Capabilities {
+	caps := Capabilities{
+		EntityID: state.EntityID,
+		Domain:   strings.SplitN(state.EntityID, ".", 2)[0],
+	}
+
+	if features, ok := numberAttribute(state, "supported_features"); ok {
+		caps.Known = true
+		caps.Features = int(features)
+	}
+
+	caps.ColorModes = stringsAttribute(state, "supported_color_modes")
+	caps.Effects = stringsAttribute(state, "effect_list")
+	caps.MinKelvin, _ = numberAttribute(state, "min_color_temp_kelvin")
+	caps.MaxKelvin, _ = numberAttribute(state, "max_color_temp_kelvin")
+
+	caps.PercentageStep, _ = numberAttribute(state, "percentage_step")
+
+	caps.HVACModes = stringsAttribute(state, "hvac_modes")
+	caps.FanModes = stringsAttribute(state, "fan_modes")
+	caps.SwingModes = stringsAttribute(state, "swing_modes")
+	caps.PresetModes = stringsAttribute(state, "preset_modes")
+	caps.MinTemp, _ = numberAttribute(state, "min_temp")
+	caps.MaxTemp, _ = numberAttribute(state, "max_temp")
+	caps.TempStep, _ = numberAttribute(state, "target_temp_step")
+	caps.MinHumidity, _ = numberAttribute(state, "min_humidity")
+	caps.MaxHumidity, _ = numberAttribute(state, "max_humidity")
+
+	caps.Sources = stringsAttribute(state, "source_list")
+	caps.SoundModes = stringsAttribute(state, "sound_mode_list")
+
+	caps.FanSpeeds = stringsAttribute(state, "fan_speed_list")
+	caps.SegmentCleaning = caps.Domain == "vacuum" && segmentCleaning(state)
+	caps.OperationModes = stringsAttribute(state, "operation_list")
+
+	caps.Min, _ = numberAttribute(state, "min")
+	caps.Max, _ = numberAttribute(state, "max")
+	caps.Step, _ = numberAttribute(state, "step")
+	if caps.Domain == "counter" {
+		caps.Min, _ = numberAttribute(state, "minimum")
+		caps.Max, _ = numberAttribute(state, "maximum")
+	}
+	caps.Options = stringsAttribute(state, "options")
+	caps.Pattern, _ = state.Attributes["pattern"].(string)
+	caps.HasDate, _ = state.Attributes["has_date"].(bool)
+	caps.HasTime, _ = state.Attributes["has_time"].(bool)
+
+	// Lights report their abilities through color modes rather than
+	// feature bits.
+	if caps.Domain == "light" && caps.ColorModes != nil {
+		caps.Known = true
+	}
+
+	return caps
+}
+
+// segmentCleaning reports whether a vacuum looks like a Xiaomi or Roborock
+// model. States do not name their integration, so this goes by the entity
+// ID, the friendly name and the brush attributes only xiaomi_miio reports.
+func segmentCleaning(state client.EntityState) bool {
+	if _, ok := state.Attributes["main_brush_left"]; ok {
+		return true
+	}
+	name, _ := state.Attributes["friendly_name"].(string)
+	name = strings.ToLower(state.EntityID + " " + name)
+	for _, brand := range []string{"roborock", "xiaomi", "rockrobo", "mijia"} {
+		if strings.Contains(name, brand) {
+			return true
+		}
+	}
+	return false
+}
+
+// Has reports whether feature is set, or true if features are unknown.
+func (c Capabilities) Has(feature int) bool {
+	return !c.Known || c.Features&feature != 0
+}
+
+// SupportsBrightness reports whether a light can be dimmed.
+func (c Capabilities) SupportsBrightness() bool {
+	if c.ColorModes == nil {
+		return true
+	}
+	for _, mode := range c.ColorModes {
+		if mode != "onoff" && mode != "unknown" {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsColor reports whether a light can change color.
+func (c Capabilities) SupportsColor() bool {
+	if c.ColorModes == nil {
+		return true
+	}
+	for _, mode := range c.ColorModes {
+		switch mode {
+		case "hs", "xy", "rgb", "rgbw", "rgbww":
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsColorTemp reports whether a light has adjustable white temperature.
+func (c Capabilities) SupportsColorTemp() bool {
+	return c.ColorModes == nil || slices.Contains(c.ColorModes, "color_temp")
+}
+
+// Actions lists the value actions the CLI can perform on the entity, for
+// suggestions.
+func (c Capabilities) Actions() []string {
+	actions := []string{"on", "off", "toggle"}
+
+	switch c.Domain {
+	case "light":
+		if c.SupportsBrightness() {
+			actions = append(actions, "brightness", "dim", "bright")
+		}
+		if c.SupportsColorTemp() {
+			actions = append(actions, "warm", "cool", "kelvin")
+		}
+		if c.SupportsColor() {
+			actions = append(actions, "color", "rgb")
+		}
+		if c.Has(LightFeatureEffect) && (len(c.Effects) > 0 || !c.Known) {
+			actions = append(actions, "effect")
+		}
+		if c.Has(LightFeatureFlash) {
+			actions = append(actions, "flash")
+		}
+	case "fan":
+		if c.Has(FanFeatureSetSpeed) {
+			actions = append(actions, "speed", "faster", "slower")
+		}
+		if c.Has(FanFeatureOscillate) {
+			actions = append(actions, "oscillate")
+		}
+		if c.Has(FanFeatureDirection) {
+			actions = append(actions, "direction")
+		}
+		if c.Has(FanFeaturePresetMode) && (len(c.PresetModes) > 0 || !c.Known) {
+			actions = append(actions, "preset")
+		}
+	case "climate":
+		if c.Has(ClimateFeatureTargetTemperature) {
+			actions = append(actions, "temperature")
+		}
+		if c.Has(ClimateFeatureTargetTemperatureRange) {
+			actions = append(actions, "range")
+		}
+		if len(c.HVACModes) > 0 || !c.Known {
+			actions = append(actions, "mode")
+		}
+		if c.Has(ClimateFeatureFanMode) {
+			actions = append(actions, "fan")
+		}
+		if c.Has(ClimateFeatureSwingMode) {
+			actions = append(actions, "swing")
+		}
+		if c.Has(ClimateFeaturePresetMode) && (len(c.PresetModes) > 0 || !c.Known) {
+			actions = append(actions, "preset")
+		}
+		if c.Has(ClimateFeatureTargetHumidity) {
+			actions = append(actions, "humidity")
+		}
+	case "cover":
+		actions = []string{"open", "close", "toggle"}
+		if c.Has(CoverFeatureStop) {
+			actions = append(actions, "stop")
+		}
+		if c.Has(CoverFeatureSetPosition) {
+			actions = append(actions, "position")
+		}
+		if c.Has(CoverFeatureOpenTilt | CoverFeatureCloseTilt | CoverFeatureSetTiltPosition) {
+			actions = append(actions, "tilt")
+		}
+	case "lock":
+		actions = []string{"lock", "unlock", "toggle"}
+		if c.Has(LockFeatureOpen) {
+			actions = append(actions, "open")
+		}
+	case "media_player":
+		if c.Has(MediaFeaturePlay) {
+			actions = append(actions, "play")
+		}
+		if c.Has(MediaFeaturePause) {
+			actions = append(actions, "pause")
+		}
+		if c.Has(MediaFeatureStop) {
+			actions = append(actions, "stop")
+		}
+		if c.Has(MediaFeatureNextTrack) {
+			actions = append(actions, "next")
+		}
+		if c.Has(MediaFeaturePreviousTrack) {
+			actions = append(actions, "previous")
+		}
+		if c.Has(MediaFeatureVolumeSet | MediaFeatureVolumeStep) {
+			actions = append(actions, "volume")
+		}
+		if c.Has(MediaFeatureVolumeMute) {
+			actions = append(actions, "mute")
+		}
+		if c.Has(MediaFeatureSelectSource) {
+			actions = append(actions, "source")
+		}
+		if c.Has(MediaFeatureSelectSoundMode) {
+			actions = append(actions, "sound_mode")
+		}
+		if c.Has(MediaFeaturePlayMedia) {
+			actions = append(actions, "play_media")
+		}
+		if c.Has(MediaFeatureShuffleSet) {
+			actions = append(actions, "shuffle")
+		}
+		if c.Has(MediaFeatureRepeatSet) {
+			actions = append(actions, "repeat")
+		}
+		if c.Has(MediaFeatureGrouping) {
+			actions = append(actions, "join", "unjoin")
+		}
+	case "vacuum":
+		actions = []string{}
+		if c.Has(VacuumFeatureStart) {
+			actions = append(actions, "start")
+		}
+		if c.Has(VacuumFeaturePause) {
+			actions = append(actions, "pause")
+		}
+		if c.Has(VacuumFeatureStop) {
+			actions = append(actions, "stop")
+		}
+		if c.Has(VacuumFeatureReturnHome) {
+			actions = append(actions, "dock")
+		}
+		if c.Has(VacuumFeatureLocate) {
+			actions = append(actions, "locate")
+		}
+		if c.Has(VacuumFeatureCleanSpot) {
+			actions = append(actions, "spot")
+		}
+		if c.Has(VacuumFeatureFanSpeed) {
+			actions = append(actions, "fan_speed")
+		}
+		if c.Has(VacuumFeatureSendCommand) && c.SegmentCleaning {
+			actions = append(actions, "segment")
+		}
+	case "lawn_mower":
+		actions = []string{}
+		if c.Has(LawnMowerFeatureStartMowing) {
+			actions = append(actions, "start")
+		}
+		if c.Has(LawnMowerFeaturePause) {
+			actions = append(actions, "pause")
+		}
+		if c.Has(LawnMowerFeatureDock) {
+			actions = append(actions, "dock")
+		}
+	case "water_heater":
+		if !c.Has(WaterHeaterFeatureOnOff) {
+			actions = []string{}
+		}
+		if c.Has(WaterHeaterFeatureTargetTemperature) {
+			actions = append(actions, "temperature")
+		}
+		if c.Has(WaterHeaterFeatureOperationMode) {
+			actions = append(actions, "mode")
+		}
+		if c.Has(WaterHeaterFeatureAwayMode) {
+			actions = append(actions, "away")
+		}
+	case "input_number":
+		actions = []string{"set", "increment", "decrement"}
+	case "number":
+		actions = []string{"set"}
+	case "input_select", "select":
+		actions = []string{"select", "next", "previous", "first", "last"}
+	case "input_text", "text":
+		actions = []string{"set", "clear"}
+	case "button", "input_button":
+		actions = []string{"press"}
+	case "input_datetime":
+		actions = []string{"set"}
+	case "counter":
+		actions = []string{"increment", "decrement", "reset", "set"}
+	case "timer":
+		actions = []string{"start", "pause", "cancel", "finish"}
+	case "alarm_control_panel":
+		actions = []string{}
+		if c.Has(AlarmFeatureArmHome) {
+			actions = append(actions, "arm_home")
+		}
+		if c.Has(AlarmFeatureArmAway) {
+			actions = append(actions, "arm_away")
+		}
+		if c.Has(AlarmFeatureArmNight) {
+			actions = append(actions, "arm_night")
+		}
+		actions = append(actions, "disarm")
+	}
+
+	return actions
+}
+
+// Unsupported returns an error for action listing what the entity supports.
+func (c Capabilities) Unsupported(action, reason string) error {
+	return &NotSupportedError{
+		EntityID:  c.EntityID,
+		Action:    action,
+		Reason:    reason,
+		Supported: c.Actions(),
+	}
+}
+
+// CheckRange verifies value is within the entity's [low, high] when the
+// bounds are known.
+func (c Capabilities) CheckRange(action string, value, low, high float64) error {
+	if low == 0 && high == 0 {
+		return nil
+	}
+	if value < low || value > high {
+		return &NotSupportedError{
+			EntityID: c.EntityID,
+			Action:   fmt.Sprintf("%s %g", action, value),
+			Reason:   fmt.Sprintf("must be between %g and %g", low, high),
+		}
+	}
+	return nil
+}
+
+// MatchOption finds value among options, ignoring case and treating spaces
+// and underscores alike. An empty options list accepts any value.
+func (c Capabilities) MatchOption(action, value string, options []string) (string, error) {
+	if len(options) == 0 {
+		return value, nil
+	}
+
+	normalize := func(s string) string {
+		return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), " ", "_")
+	}
+	for _, option := range options {
+		if normalize(option) == normalize(value) {
+			return option, nil
+		}
+	}
+
+	return "", &NotSupportedError{
+		EntityID:  c.EntityID,
+		Action:    fmt.Sprintf("%s %q", action, value),
+		Supported: options,
+	}
+}
+
+// optionMatchThreshold is the fuzzy score an option needs to be picked by
+// MatchOptionFuzzy.
+const optionMatchThreshold = 0.7
+
+// MatchOptionFuzzy is MatchOption, falling back to the closest option by
+// fuzzy score when none matches exactly. A tie between the closest options
+// is an error listing them.
+func (c Capabilities) MatchOptionFuzzy(action, value string, options []string) (string, error) {
+	option, err := c.MatchOption(action, value, options)
+	if err == nil {
+		return option, nil
+	}
+
+	var best []string
+	bestScore := optionMatchThreshold
+	for _, candidate := range options {
+		score := fuzzyScore(strings.ToLower(candidate), strings.ToLower(value))
+		switch {
+		case score > bestScore:
+			best, bestScore = []string{candidate}, score
+		case score == bestScore:
+			best = append(best, candidate)
+		}
+	}
+
+	switch len(best) {
+	case 0:
+		return "", err
+	case 1:
+		return best[0], nil
+	default:
+		return "", &NotSupportedError{
+			EntityID:  c.EntityID,
+			Action:    fmt.Sprintf("%s %q", action, value),
+			Reason:    "it matches more than one option",
+			Supported: best,
+		}
+	}
+}
+
+func numberAttribute(state client.EntityState, name string) (float64, bool) {
+	switch value := state.Attributes[name].(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	default:
+		return 0, false
+	}
+}
+
+func stringsAttribute(state client.EntityState, name string) []string {
+	var values []string
+	switch list := state.Attributes[name].(type) {
+	case []interface{}:
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		if values == nil {
+			values = []string{}
+		}
+	case []string:
+		values = list
+	}
+	return values
+}
@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+func TestCapabilitiesOfLight(t *testing.T) {
+	tests := []struct {
+		name       string
+		modes      []interface{}
+		brightness bool
+		color      bool
+		colorTemp  bool
+	}{
+		{"on/off bulb", []interface{}{"onoff"}, false, false, false},
+		{"dimmable", []interface{}{"brightness"}, true, false, false},
+		{"white spectrum", []interface{}{"color_temp"}, true, false, true},
+		{"color", []interface{}{"color_temp", "xy"}, true, true, true},
+		{"no modes reported", nil, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributes := map[string]interface{}{"supported_features": float64(LightFeatureTransition)}
+			if tt.modes != nil {
+				attributes["supported_color_modes"] = tt.modes
+			}
+			caps := CapabilitiesOf(client.EntityState{EntityID: "light.kitchen", Attributes: attributes})
+
+			if caps.SupportsBrightness() != tt.brightness {
+				t.Errorf("SupportsBrightness() = %v, expected %v", caps.SupportsBrightness(), tt.brightness)
+			}
+			if caps.SupportsColor() != tt.color {
+				t.Errorf("SupportsColor() = %v, expected %v", caps.SupportsColor(), tt.color)
+			}
+			if caps.SupportsColorTemp() != tt.colorTemp {
+				t.Errorf("SupportsColorTemp() = %v, expected %v", caps.SupportsColorTemp(), tt.colorTemp)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesOfClimate(t *testing.T) {
+	caps := CapabilitiesOf(client.EntityState{
+		EntityID: "climate.hallway",
+		Attributes: map[string]interface{}{
+			"supported_features": float64(ClimateFeatureTargetTemperatureRange | ClimateFeatureFanMode),
+			"hvac_modes":         []interface{}{"off", "heat_cool"},
+			"min_temp":           float64(7),
+			"max_temp":           float64(35),
+		},
+	})
+
+	if caps.Has(ClimateFeatureTargetTemperature) {
+		t.Error("expected no single target temperature")
+	}
+	if !caps.Has(ClimateFeatureTargetTemperatureRange) || !caps.Has(ClimateFeatureFanMode) {
+		t.Error("expected range and fan mode support")
+	}
+
+	if err := caps.CheckRange("temperature", 40, caps.MinTemp, caps.MaxTemp); err == nil || !strings.Contains(err.Error(), "between 7 and 35") {
+		t.Errorf("expected a range error, got %v", err)
+	}
+
+	mode, err := caps.MatchOption("mode", "Heat Cool", caps.HVACModes)
+	if err != nil || mode != "heat_cool" {
+		t.Errorf("expected heat_cool, got %q (err=%v)", mode, err)
+	}
+
+	_, err = caps.MatchOption("mode", "cool", caps.HVACModes)
+	if !errors.Is(err, ErrNotSupported) || !strings.Contains(err.Error(), "supported: off, heat_cool") {
+		t.Errorf("expected the supported modes to be suggested, got %v", err)
+	}
+}
+
+func TestCapabilitiesUnknownFeatures(t *testing.T) {
+	caps := CapabilitiesOf(client.EntityState{EntityID: "fan.attic"})
+
+	if caps.Known || !caps.Has(FanFeatureSetSpeed) {
+		t.Error("expected an entity without supported_features to allow everything")
+	}
+}
+
+func TestUnsupportedSuggestsActions(t *testing.T) {
+	caps := CapabilitiesOf(client.EntityState{
+		EntityID:   "light.porch",
+		Attributes: map[string]interface{}{"supported_features": float64(0), "supported_color_modes": []interface{}{"onoff"}},
+	})
+
+	err := caps.Unsupported("brightness", "it can only be switched on and off")
+	if err.Error() != "light.porch does not support brightness: it can only be switched on and off (supported: on, off, toggle)" {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestMatchOptionFuzzy(t *testing.T) {
+	caps := Capabilities{EntityID: "input_select.house_mode"}
+	options := []string{"Home", "Away", "Movie Night", "Good Night"}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"movie night", "Movie Night"},
+		{"movie", "Movie Night"},
+		{"awy", "Away"},
+		{"good_night", "Good Night"},
+	}
+
+	for _, tt := range tests {
+		got, err := caps.MatchOptionFuzzy("option", tt.value, options)
+		if err != nil || got != tt.want {
+			t.Errorf("MatchOptionFuzzy(%q) = %q (err=%v), expected %q", tt.value, got, err, tt.want)
+		}
+	}
+
+	if _, err := caps.MatchOptionFuzzy("option", "night", options); err == nil || !strings.Contains(err.Error(), "more than one") {
+		t.Errorf("expected an ambiguous match to be an error, got %v", err)
+	}
+	if _, err := caps.MatchOptionFuzzy("option", "vacation", options); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected no match, got %v", err)
+	}
+}
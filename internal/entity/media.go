@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+// NowPlaying describes what a media player is playing.
+type NowPlaying struct {
+	Title  string
+	Artist string
+	Album  string
+	// Source is the app or input the media comes from.
+	Source   string
+	Position time.Duration
+	Duration time.Duration
+	// Volume is between 0 and 1, or -1 when the player does not report it.
+	Volume float64
+	Muted  bool
+}
+
+// NowPlayingOf reads the media attributes of a media player. The position
+// is advanced to now while the player is playing. It reports false when
+// nothing is loaded.
+func NowPlayingOf(state client.EntityState, now time.Time) (NowPlaying, bool) {
+	np := NowPlaying{Volume: -1}
+
+	np.Title, _ = state.Attributes["media_title"].(string)
+	for _, name := range []string{"media_artist", "media_album_artist", "media_series_title", "media_channel"} {
+		if np.Artist, _ = state.Attributes[name].(string); np.Artist != "" {
+			break
+		}
+	}
+	np.Album, _ = state.Attributes["media_album_name"].(string)
+	if np.Source, _ = state.Attributes["app_name"].(string); np.Source == "" {
+		np.Source, _ = state.Attributes["source"].(string)
+	}
+
+	if volume, ok := numberAttribute(state, "volume_level"); ok {
+		np.Volume = volume
+	}
+	np.Muted, _ = state.Attributes["is_volume_muted"].(bool)
+
+	if duration, ok := numberAttribute(state, "media_duration"); ok {
+		np.Duration = seconds(duration)
+	}
+	if position, ok := numberAttribute(state, "media_position"); ok {
+		np.Position = seconds(position)
+		updated, _ := state.Attributes["media_position_updated_at"].(string)
+		if at, err := time.Parse(time.RFC3339Nano, updated); err == nil && state.State == "playing" && now.After(at) {
+			np.Position += now.Sub(at)
+		}
+		if np.Duration > 0 && np.Position > np.Duration {
+			np.Position = np.Duration
+		}
+	}
+
+	return np, np.Title != "" || np.Artist != ""
+}
+
+// Summary returns "Title — Artist", or whichever of them is known.
+func (np NowPlaying) Summary() string {
+	switch {
+	case np.Title != "" && np.Artist != "":
+		return np.Title + " — " + np.Artist
+	case np.Title != "":
+		return np.Title
+	default:
+		return np.Artist
+	}
+}
+
+// Progress returns the position as "1:23 / 4:56", or "" when unknown.
+func (np NowPlaying) Progress() string {
+	if np.Duration <= 0 {
+		if np.Position > 0 {
+			return formatMediaTime(np.Position)
+		}
+		return ""
+	}
+	return formatMediaTime(np.Position) + " / " + formatMediaTime(np.Duration)
+}
+
+// VolumeText returns the volume as "35%", with "(muted)" when muted, or ""
+// when unknown.
+func (np NowPlaying) VolumeText() string {
+	var parts []string
+	if np.Volume >= 0 {
+		parts = append(parts, fmt.Sprintf("%.0f%%", np.Volume*100))
+	}
+	if np.Muted {
+		parts = append(parts, "(muted)")
+	}
+	return strings.Join(parts, " ")
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func formatMediaTime(d time.Duration) string {
+	total := int(d / time.Second)
+	hours, minutes, secs := total/3600, total/60%60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, secs)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, secs)
+}
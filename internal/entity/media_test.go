@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quinncuatro/hass-cli/internal/client"
+)
+
+func TestNowPlayingOf(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 20, 0, 0, 0, time.UTC)
+	state := client.EntityState{
+		EntityID: "media_player.living_room",
+		State:    "playing",
+		Attributes: map[string]interface{}{
+			"media_title":               "So What",
+			"media_album_artist":        "Miles Davis",
+			"media_album_name":          "Kind of Blue",
+			"source":                    "Spotify",
+			"volume_level":              0.35,
+			"media_duration":            float64(562),
+			"media_position":            float64(60),
+			"media_position_updated_at": updated.Format(time.RFC3339Nano),
+		},
+	}
+
+	np, ok := NowPlayingOf(state, updated.Add(23*time.Second))
+	if !ok {
+		t.Fatal("expected something to be playing")
+	}
+	if got := np.Summary(); got != "So What — Miles Davis" {
+		t.Errorf("unexpected summary %q", got)
+	}
+	if got := np.Progress(); got != "1:23 / 9:22" {
+		t.Errorf("expected the position to advance while playing, got %q", got)
+	}
+	if got := np.VolumeText(); got != "35%" {
+		t.Errorf("unexpected volume %q", got)
+	}
+
+	state.State = "paused"
+	if np, _ := NowPlayingOf(state, updated.Add(time.Hour)); np.Progress() != "1:00 / 9:22" {
+		t.Errorf("expected a paused position to stay put, got %q", np.Progress())
+	}
+
+	state.State = "playing"
+	if np, _ := NowPlayingOf(state, updated.Add(time.Hour)); np.Progress() != "9:22 / 9:22" {
+		t.Errorf("expected the position to stop at the duration, got %q", np.Progress())
+	}
+
+	if _, ok := NowPlayingOf(client.EntityState{EntityID: "media_player.tv", State: "idle"}, updated); ok {
+		t.Error("expected nothing playing on an idle player")
+	}
+}
@@ -2,19 +2,27 @@ package entity
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/quinncuatro/hass-cli/internal/client"
 	"github.com/quinncuatro/hass-cli/internal/config"
 )
 
+// refetchAfter is how old cached states must be before a failed lookup
+// fetches them again.
+const refetchAfter = 5 * time.Second
+
+var ErrEntityNotFound = errors.New("no entities found matching criteria")
+
 type Resolver struct {
 	config *config.Config
-	client *client.HomeAssistantClient
+	client client.Client
 }
 
 type EntityMatch struct {
@@ -34,6 +42,23 @@ const (
 	EntityTypeClimate
 	EntityTypeCover
 	EntityTypeSensor
+	EntityTypeLock
+	EntityTypeAlarm
+	EntityTypeMediaPlayer
+	EntityTypeVacuum
+	EntityTypeLawnMower
+	EntityTypeWaterHeater
+	EntityTypeInputBoolean
+	EntityTypeInputNumber
+	EntityTypeInputSelect
+	EntityTypeInputText
+	EntityTypeInputDatetime
+	EntityTypeCounter
+	EntityTypeTimer
+	EntityTypeButton
+	EntityTypeNumber
+	EntityTypeSelect
+	EntityTypeText
 	EntityTypeUnknown
 )
 
@@ -51,6 +76,40 @@ func (et EntityType) String() string {
 		return "cover"
 	case EntityTypeSensor:
 		return "sensor"
+	case EntityTypeLock:
+		return "lock"
+	case EntityTypeAlarm:
+		return "alarm_control_panel"
+	case EntityTypeMediaPlayer:
+		return "media_player"
+	case EntityTypeVacuum:
+		return "vacuum"
+	case EntityTypeLawnMower:
+		return "lawn_mower"
+	case EntityTypeWaterHeater:
+		return "water_heater"
+	case EntityTypeInputBoolean:
+		return "input_boolean"
+	case EntityTypeInputNumber:
+		return "input_number"
+	case EntityTypeInputSelect:
+		return "input_select"
+	case EntityTypeInputText:
+		return "input_text"
+	case EntityTypeInputDatetime:
+		return "input_datetime"
+	case EntityTypeCounter:
+		return "counter"
+	case EntityTypeTimer:
+		return "timer"
+	case EntityTypeButton:
+		return "button"
+	case EntityTypeNumber:
+		return "number"
+	case EntityTypeSelect:
+		return "select"
+	case EntityTypeText:
+		return "text"
 	default:
 		return "unknown"
 	}
@@ -60,22 +119,48 @@ func (et EntityType) Domain() string {
 	return et.String()
 }
 
-func NewResolver(cfg *config.Config, client *client.HomeAssistantClient) *Resolver {
+func NewResolver(cfg *config.Config, client client.Client) *Resolver {
 	return &Resolver{
 		config: cfg,
 		client: client,
 	}
 }
 
-func (r *Resolver) ResolveEntity(ctx context.Context, area, entityType, entityName string) (*EntityMatch, error) {
+// States returns the entity list used for name resolution. It may be served
+// from a cache while a refresh runs in the background.
+func (r *Resolver) States(ctx context.Context) ([]client.EntityState, error) {
+	states, _, err := r.cachedStates(ctx)
+	return states, err
+}
+
+func (r *Resolver) cachedStates(ctx context.Context) ([]client.EntityState, time.Time, error) {
+	if stateCache, ok := r.client.(client.StateCache); ok {
+		return stateCache.CachedStates(ctx)
+	}
+
 	states, err := r.client.GetStates(ctx)
+	return states, time.Now(), err
+}
+
+func (r *Resolver) ResolveEntity(ctx context.Context, area, entityType, entityName string) (*EntityMatch, error) {
+	states, fetchedAt, err := r.cachedStates(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch entities: %w", err)
 	}
 
 	matches := r.findMatches(states, area, entityType, entityName)
+	if len(matches) == 0 && time.Since(fetchedAt) > refetchAfter {
+		// The entity may be newer than the cache; look again with fresh data.
+		if stateCache, ok := r.client.(client.StateCache); ok {
+			stateCache.Invalidate()
+		}
+		if states, err = r.client.GetStates(ctx); err != nil {
+			return nil, fmt.Errorf("failed to fetch entities: %w", err)
+		}
+		matches = r.findMatches(states, area, entityType, entityName)
+	}
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("no entities found matching criteria")
+		return nil, ErrEntityNotFound
 	}
 
 	if len(matches) == 1 {
@@ -158,7 +243,16 @@ func (r *Resolver) scoreEntity(state client.EntityState, area, entityType, entit
 
 	if entityName != "" {
 		nameScore := r.scoreName(match.FriendlyName, entityName)
-		score += nameScore * 0.3
+		if strings.EqualFold(entityName, state.EntityID) {
+			nameScore = 1.0
+		}
+		// A bare name query (status, wait) is scored on the name alone,
+		// otherwise it could never clear the fuzzy threshold
+		if area == "" && entityType == "" {
+			score += nameScore
+		} else {
+			score += nameScore * 0.3
+		}
 	}
 
 	// Penalize unavailable entities slightly
@@ -176,28 +270,71 @@ func (r *Resolver) scoreEntity(state client.EntityState, area, entityType, entit
 	return match
 }
 
+var domainKeywords = map[string][]string{
+	"light":   {"light", "lights", "lamp", "lamps", "bulb", "bulbs"},
+	"switch":  {"switch", "switches", "outlet", "outlets", "plug", "plugs"},
+	"fan":     {"fan", "fans", "ceiling", "exhaust"},
+	"climate": {"climate", "thermostat", "ac", "heat", "temp", "temperature", "hvac"},
+	"cover":   {"cover", "covers", "blind", "blinds", "curtain", "curtains", "shade", "shades", "garage", "door", "doors"},
+	"sensor":  {"sensor", "sensors", "temperature", "humidity", "motion", "occupancy"},
+	"lock":    {"lock", "locks", "deadbolt"},
+
+	"alarm_control_panel": {"alarm", "alarms", "security"},
+	"media_player":        {"speaker", "speakers", "tv", "media", "player"},
+	"vacuum":              {"vacuum", "vacuums", "roomba", "robot"},
+	"lawn_mower":          {"mower", "mowers"},
+	"water_heater":        {"heater", "boiler"},
+	"input_boolean":       {"boolean", "booleans", "flag", "flags"},
+	"input_number":        {"number", "numbers", "slider"},
+	"input_select":        {"select", "dropdown", "options"},
+	"input_text":          {"text"},
+	"input_datetime":      {"datetime", "date", "time"},
+	"counter":             {"counter", "counters"},
+	"timer":               {"timer", "timers"},
+	"button":              {"button", "buttons"},
+	"input_button":        {"button", "buttons"},
+	"number":              {"number", "numbers"},
+	"select":              {"select"},
+	"text":                {"text"},
+}
+
+// EntityTypeKeywords returns every word that can name an entity type, sorted.
+func EntityTypeKeywords() []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, words := range domainKeywords {
+		for _, word := range words {
+			if !seen[word] {
+				seen[word] = true
+				keywords = append(keywords, word)
+			}
+		}
+	}
+	sort.Strings(keywords)
+	return keywords
+}
+
 func (r *Resolver) scoreDomain(domain, entityType string) float64 {
 	normalizedType := strings.ToLower(entityType)
-	
-	domainMappings := map[string][]string{
-		"light":   {"light", "lights", "lamp", "lamps", "bulb", "bulbs"},
-		"switch":  {"switch", "switches", "outlet", "outlets", "plug", "plugs"},
-		"fan":     {"fan", "fans", "ceiling", "exhaust"},
-		"climate": {"climate", "thermostat", "ac", "heat", "temp", "temperature", "hvac"},
-		"cover":   {"cover", "covers", "blind", "blinds", "curtain", "curtains", "shade", "shades", "garage", "door", "doors"},
-		"sensor":  {"sensor", "sensors", "temperature", "humidity", "motion", "occupancy"},
-	}
-
-	for targetDomain, keywords := range domainMappings {
-		for _, keyword := range keywords {
-			if strings.Contains(normalizedType, keyword) {
-				if domain == targetDomain {
-					return 1.0
-				} else {
-					return 0.0
+
+	// Exact keywords are tried first, so "vacuum" is not taken for the "ac"
+	// in climate, nor "heater" for "heat". A keyword may name several
+	// domains, as "temperature" does.
+	for _, exact := range []bool{true, false} {
+		matched := false
+		for targetDomain, keywords := range domainKeywords {
+			for _, keyword := range keywords {
+				if normalizedType == keyword || (!exact && strings.Contains(normalizedType, keyword)) {
+					if domain == targetDomain {
+						return 1.0
+					}
+					matched = true
 				}
 			}
 		}
+		if matched {
+			return 0.0
+		}
 	}
 
 	return 0.0
@@ -268,12 +405,18 @@ func (r *Resolver) scoreName(friendlyName, entityName string) float64 {
 }
 
 func (r *Resolver) fuzzyMatch(s1, s2 string) float64 {
+	return fuzzyScore(s1, s2)
+}
+
+// fuzzyScore rates how closely two names match, from 0 (unrelated) to 1
+// (equal once case and punctuation are ignored).
+func fuzzyScore(s1, s2 string) float64 {
 	if s1 == s2 {
 		return 1.0
 	}
 
-	s1 = r.normalizeString(s1)
-	s2 = r.normalizeString(s2)
+	s1 = normalizeString(s1)
+	s2 = normalizeString(s2)
 
 	if s1 == s2 {
 		return 1.0
@@ -300,10 +443,10 @@ func (r *Resolver) fuzzyMatch(s1, s2 string) float64 {
 		}
 	}
 
-	return r.levenshteinSimilarity(s1, s2)
+	return levenshteinSimilarity(s1, s2)
 }
 
-func (r *Resolver) normalizeString(s string) string {
+func normalizeString(s string) string {
 	var result strings.Builder
 	for _, r := range s {
 		if unicode.IsLetter(r) || unicode.IsDigit(r) {
@@ -315,12 +458,12 @@ func (r *Resolver) normalizeString(s string) string {
 	return strings.TrimSpace(result.String())
 }
 
-func (r *Resolver) levenshteinSimilarity(s1, s2 string) float64 {
+func levenshteinSimilarity(s1, s2 string) float64 {
 	if len(s1) == 0 || len(s2) == 0 {
 		return 0.0
 	}
 
-	distance := r.levenshteinDistance(s1, s2)
+	distance := levenshteinDistance(s1, s2)
 	maxLen := len(s1)
 	if len(s2) > maxLen {
 		maxLen = len(s2)
@@ -329,7 +472,7 @@ func (r *Resolver) levenshteinSimilarity(s1, s2 string) float64 {
 	return 1.0 - float64(distance)/float64(maxLen)
 }
 
-func (r *Resolver) levenshteinDistance(s1, s2 string) int {
+func levenshteinDistance(s1, s2 string) int {
 	if len(s1) == 0 {
 		return len(s2)
 	}
@@ -388,11 +531,50 @@ func ParseEntityType(s string) EntityType {
 		return EntityTypeCover
 	case "sensor", "sensors":
 		return EntityTypeSensor
+	case "lock", "locks", "deadbolt":
+		return EntityTypeLock
+	case "alarm", "alarms", "security", "alarm_control_panel":
+		return EntityTypeAlarm
+	case "speaker", "speakers", "tv", "media", "player", "media_player":
+		return EntityTypeMediaPlayer
+	case "vacuum", "vacuums", "roomba", "robot":
+		return EntityTypeVacuum
+	case "mower", "mowers", "lawn_mower":
+		return EntityTypeLawnMower
+	case "heater", "boiler", "water_heater":
+		return EntityTypeWaterHeater
+	case "boolean", "booleans", "flag", "flags", "input_boolean":
+		return EntityTypeInputBoolean
+	case "slider", "input_number":
+		return EntityTypeInputNumber
+	case "dropdown", "options", "input_select":
+		return EntityTypeInputSelect
+	case "input_text":
+		return EntityTypeInputText
+	case "datetime", "date", "time", "input_datetime":
+		return EntityTypeInputDatetime
+	case "counter", "counters":
+		return EntityTypeCounter
+	case "timer", "timers":
+		return EntityTypeTimer
+	case "button", "buttons":
+		return EntityTypeButton
+	case "number", "numbers":
+		return EntityTypeNumber
+	case "select":
+		return EntityTypeSelect
+	case "text":
+		return EntityTypeText
 	default:
 		return EntityTypeUnknown
 	}
 }
 
+// ActionKeywords returns the action words understood by ParseAction.
+func ActionKeywords() []string {
+	return []string{"on", "off", "toggle", "enable", "disable", "open", "close"}
+}
+
 func ParseAction(s string) string {
 	switch strings.ToLower(s) {
 	case "on", "turn_on", "enable", "open":
@@ -26,6 +26,15 @@ func TestParseEntityType(t *testing.T) {
 		{"cover", EntityTypeCover},
 		{"blinds", EntityTypeCover},
 		{"sensor", EntityTypeSensor},
+		{"lock", EntityTypeLock},
+		{"alarm", EntityTypeAlarm},
+		{"vacuum", EntityTypeVacuum},
+		{"mower", EntityTypeLawnMower},
+		{"heater", EntityTypeWaterHeater},
+		{"counter", EntityTypeCounter},
+		{"button", EntityTypeButton},
+		{"number", EntityTypeNumber},
+		{"slider", EntityTypeInputNumber},
 		{"unknown", EntityTypeUnknown},
 	}
 
@@ -108,7 +117,19 @@ func TestResolverScoreDomain(t *testing.T) {
 		{"fan", "fan", 1.0},
 		{"climate", "thermostat", 1.0},
 		{"cover", "blinds", 1.0},
+		{"cover", "door", 1.0},
+		{"lock", "door", 0.0},
 		{"sensor", "sensor", 1.0},
+		{"vacuum", "vacuum", 1.0},
+		{"climate", "vacuum", 0.0},
+		{"water_heater", "heater", 1.0},
+		{"climate", "heater", 0.0},
+		{"lawn_mower", "mower", 1.0},
+		{"input_number", "number", 1.0},
+		{"number", "number", 1.0},
+		{"input_button", "button", 1.0},
+		{"timer", "timer", 1.0},
+		{"input_datetime", "timer", 0.0},
 		{"light", "switch", 0.0},
 		{"unknown", "light", 0.0},
 	}
@@ -184,4 +205,53 @@ func TestResolverScoreEntity(t *testing.T) {
 	if match.Score <= 0.6 {
 		t.Errorf("expected Score to be above threshold, got %f", match.Score)
 	}
-}
\ No newline at end of file
+}
+
+func TestResolverDoorPrefersCover(t *testing.T) {
+	cfg := config.DefaultConfig()
+	resolver := &Resolver{config: cfg}
+
+	states := []client.EntityState{
+		{EntityID: "lock.front_door", State: "locked", Attributes: map[string]interface{}{"friendly_name": "Front Door"}},
+		{EntityID: "cover.front_door", State: "closed", Attributes: map[string]interface{}{"friendly_name": "Front Door"}},
+	}
+
+	var cover, lock float64
+	for _, match := range resolver.DebugFindMatches(states, "front", "door", "") {
+		switch match.Domain {
+		case "cover":
+			cover = match.Score
+		case "lock":
+			lock = match.Score
+		}
+	}
+	if cover <= lock {
+		t.Errorf("expected \"front door\" to prefer the cover, got cover %f and lock %f", cover, lock)
+	}
+
+	// Locking names the lock explicitly, so it still finds the lock.
+	matches := resolver.findMatches(states, "front", "lock", "")
+	if len(matches) != 1 || matches[0].EntityID != "lock.front_door" {
+		t.Errorf("expected \"front lock\" to match only the lock, got %+v", matches)
+	}
+}
+
+func TestResolverScoreEntityByNameOnly(t *testing.T) {
+	cfg := config.DefaultConfig()
+	resolver := &Resolver{config: cfg}
+
+	state := client.EntityState{
+		EntityID: "switch.kitchen_coffee",
+		State:    "off",
+		Attributes: map[string]interface{}{
+			"friendly_name": "Kitchen Coffee Maker",
+		},
+	}
+
+	for _, query := range []string{"kitchen coffee", "Kitchen Coffee Maker", "switch.kitchen_coffee"} {
+		match := resolver.scoreEntity(state, "", "", query)
+		if match.Score <= cfg.Preferences.FuzzyThreshold {
+			t.Errorf("expected %q to score above threshold, got %f", query, match.Score)
+		}
+	}
+}
@@ -8,19 +8,19 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/quinncuatro/hass-cli/internal/client"
 	"github.com/quinncuatro/hass-cli/internal/config"
+	"github.com/quinncuatro/hass-cli/internal/entity"
 )
 
 type App struct {
 	config *config.Config
-	client *client.HomeAssistantClient
+	client client.Client
 }
 
 type model struct {
 	config     *config.Config
-	client     *client.HomeAssistantClient
+	client     client.Client
 	entities   []client.EntityState
 	cursor     int
 	selected   map[int]struct{}
@@ -30,43 +30,22 @@ type model struct {
 	loading    bool
 	err        error
 	statusMsg  string
+	configErr  string
+	styles     styles
+	keys       map[string]string
 }
 
 type entitiesLoadedMsg []client.EntityState
 type errorMsg error
 type statusMsg string
 
-var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#7D56F4")).
-			Padding(0, 1)
-
-	itemStyle = lipgloss.NewStyle().
-			Padding(0, 2)
-
-	selectedItemStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#EE6FF8")).
-				Bold(true).
-				Padding(0, 2)
-
-	paginationStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#626262"))
-
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#626262"))
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FF0000")).
-			Bold(true)
-
-	statusStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#04B575")).
-			Bold(true)
-)
+// configReloadedMsg carries a config re-read after config.yaml changed.
+type configReloadedMsg struct {
+	config *config.Config
+	err    error
+}
 
-func NewApp(cfg *config.Config, client *client.HomeAssistantClient) *App {
+func NewApp(cfg *config.Config, client client.Client) *App {
 	return &App{
 		config: cfg,
 		client: client,
@@ -79,13 +58,20 @@ func (a *App) Run(ctx context.Context) error {
 		client:   a.client,
 		selected: make(map[int]struct{}),
 		loading:  true,
+		styles:   newStyles(a.config),
+		keys:     newKeyMap(a.config),
 	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
-	
+
+	// Reloads are applied in Update, which owns the config while running.
+	go config.Watch(ctx, a.config, func(cfg *config.Config, err error) {
+		p.Send(configReloadedMsg{config: cfg, err: err})
+	})
+
 	// Load entities in background
 	go func() {
-		entities, err := a.client.GetStates(ctx)
+		entities, err := client.Uncached(a.client).GetStates(ctx)
 		if err != nil {
 			p.Send(errorMsg(err))
 			return
@@ -119,6 +105,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = error(msg)
 		m.loading = false
 
+	case configReloadedMsg:
+		if msg.err != nil {
+			m.configErr = "Config not reloaded: " + msg.err.Error()
+			return m, nil
+		}
+		restart := m.config.Reload(msg.config)
+		m.styles = newStyles(m.config)
+		m.keys = newKeyMap(m.config)
+		m.configErr = ""
+		status := "✓ Config reloaded"
+		if len(restart) > 0 {
+			status += "; restart to apply " + strings.Join(restart, ", ")
+		}
+		return m, func() tea.Msg { return statusMsg(status) }
+
 	case statusMsg:
 		m.statusMsg = string(msg)
 		return m, tea.Tick(time.Second*3, func(t time.Time) tea.Msg {
@@ -130,27 +131,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch m.keys[msg.String()] {
+		case "quit":
 			return m, tea.Quit
 
-		case "up", "k":
+		case "up":
 			if m.cursor > 0 {
 				m.cursor--
 			}
 
-		case "down", "j":
+		case "down":
 			if m.cursor < len(m.entities)-1 {
 				m.cursor++
 			}
 
-		case "enter", "space":
+		case "toggle":
 			return m, m.toggleEntity()
 
-		case "r":
+		case "refresh":
 			m.loading = true
 			return m, m.refreshEntities()
+		}
 
+		switch msg.String() {
 		case "/":
 			// TODO: Implement search/filter
 			return m, nil
@@ -170,18 +173,22 @@ func (m model) View() string {
 	}
 
 	if m.err != nil {
-		return fmt.Sprintf("\n  %s\n", errorStyle.Render("Error: "+m.err.Error()))
+		return fmt.Sprintf("\n  %s\n", m.styles.error.Render("Error: "+m.err.Error()))
 	}
 
 	var b strings.Builder
 
 	// Title
-	b.WriteString(titleStyle.Render("Home Assistant - Entity Control"))
+	b.WriteString(m.styles.title.Render("Home Assistant - Entity Control"))
 	b.WriteString("\n\n")
 
 	// Status message
 	if m.statusMsg != "" {
-		b.WriteString(statusStyle.Render(m.statusMsg))
+		b.WriteString(m.styles.status.Render(m.statusMsg))
+		b.WriteString("\n\n")
+	}
+	if m.configErr != "" {
+		b.WriteString(m.styles.error.Render(m.configErr))
 		b.WriteString("\n\n")
 	}
 
@@ -198,23 +205,21 @@ func (m model) View() string {
 
 		// State indicator
 		stateIcon := "○"
-		stateColor := lipgloss.Color("#626262")
+		stateStyle := m.styles.other
 		switch entity.State {
 		case "on":
 			stateIcon = "●"
-			stateColor = lipgloss.Color("#04B575")
+			stateStyle = m.styles.on
 		case "off":
 			stateIcon = "○"
-			stateColor = lipgloss.Color("#626262")
+			stateStyle = m.styles.off
 		case "unavailable":
 			stateIcon = "✗"
-			stateColor = lipgloss.Color("#FF0000")
+			stateStyle = m.styles.unavailable
 		default:
 			stateIcon = "◐"
-			stateColor = lipgloss.Color("#FFB86C")
 		}
 
-		stateStyle := lipgloss.NewStyle().Foreground(stateColor)
 		prefix := fmt.Sprintf("%s %s", stateStyle.Render(stateIcon), friendlyName)
 		
 		// Add domain info
@@ -224,9 +229,9 @@ func (m model) View() string {
 		line := prefix + suffix
 
 		if i == m.cursor {
-			b.WriteString(selectedItemStyle.Render("> " + line))
+			b.WriteString(m.styles.selectedItem.Render("> " + line))
 		} else {
-			b.WriteString(itemStyle.Render("  " + line))
+			b.WriteString(m.styles.item.Render("  " + line))
 		}
 		b.WriteString("\n")
 	}
@@ -234,20 +239,31 @@ func (m model) View() string {
 	// Pagination info
 	if len(m.entities) > 0 {
 		b.WriteString("\n")
-		b.WriteString(paginationStyle.Render(fmt.Sprintf("Showing %d-%d of %d entities", start+1, end, len(m.entities))))
+		b.WriteString(m.styles.pagination.Render(fmt.Sprintf("Showing %d-%d of %d entities", start+1, end, len(m.entities))))
+	}
+
+	// Details of the entity under the cursor
+	if details := m.details(); len(details) > 0 {
+		b.WriteString("\n\n")
+		b.WriteString(m.styles.pagination.Render(strings.Join(details, "\n")))
 	}
 
 	// Help
 	b.WriteString("\n\n")
-	help := "↑/k: up • ↓/j: down • enter/space: toggle • r: refresh • q: quit"
-	b.WriteString(helpStyle.Render(help))
+	var help []string
+	for _, action := range config.TUIActions {
+		if keys := keyHelp(m.keys, action); keys != "" {
+			help = append(help, keys+": "+action)
+		}
+	}
+	b.WriteString(m.styles.help.Render(strings.Join(help, " • ")))
 
 	return b.String()
 }
 
 func (m *model) filterAndSortEntities() {
 	// Filter to controllable entities (lights, switches, fans, etc.)
-	controllable := []string{"light", "switch", "fan", "climate", "cover"}
+	controllable := []string{"light", "switch", "fan", "climate", "cover", "media_player"}
 	var filtered []client.EntityState
 	
 	for _, entity := range m.entities {
@@ -285,7 +301,7 @@ func (m *model) filterAndSortEntities() {
 }
 
 func (m model) paginate() (int, int) {
-	maxItems := m.height - 8 // Leave room for title, help, etc.
+	maxItems := m.height - 12 // Leave room for title, details, help, etc.
 	if maxItems <= 0 {
 		maxItems = 10
 	}
@@ -313,6 +329,34 @@ func (m model) paginate() (int, int) {
 	return start, end
 }
 
+// details describes the entity under the cursor: its ID and state, and for
+// media players what is playing.
+func (m model) details() []string {
+	if m.cursor >= len(m.entities) {
+		return nil
+	}
+
+	state := m.entities[m.cursor]
+	lines := []string{fmt.Sprintf("%s: %s", state.EntityID, state.State)}
+
+	if strings.HasPrefix(state.EntityID, "media_player.") {
+		if np, ok := entity.NowPlayingOf(state, time.Now()); ok {
+			lines = append(lines, "♪ "+np.Summary())
+			var info []string
+			for _, text := range []string{np.Progress(), np.Source, np.VolumeText()} {
+				if text != "" {
+					info = append(info, text)
+				}
+			}
+			if len(info) > 0 {
+				lines = append(lines, "  "+strings.Join(info, " • "))
+			}
+		}
+	}
+
+	return lines
+}
+
 func (m model) toggleEntity() tea.Cmd {
 	if m.cursor >= len(m.entities) {
 		return nil
@@ -356,7 +400,8 @@ func (m model) refreshEntities() tea.Cmd {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		entities, err := m.client.GetStates(ctx)
+		// An explicit refresh should show current states, not cached ones.
+		entities, err := client.Uncached(m.client).GetStates(ctx)
 		if err != nil {
 			return errorMsg(err)
 		}
@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/quinncuatro/hass-cli/internal/config"
+)
+
+type palette struct {
+	titleFg, titleBg, selected, muted, err, ok, other string
+}
+
+var palettes = map[string]palette{
+	"default": {
+		titleFg: "#FAFAFA", titleBg: "#7D56F4", selected: "#EE6FF8",
+		muted: "#626262", err: "#FF0000", ok: "#04B575", other: "#FFB86C",
+	},
+	"light": {
+		titleFg: "#FFFFFF", titleBg: "#5A3FC0", selected: "#A3159A",
+		muted: "#767676", err: "#C00000", ok: "#007A4D", other: "#B35C00",
+	},
+}
+
+type styles struct {
+	title, item, selectedItem, pagination, help, error, status lipgloss.Style
+	on, off, unavailable, other                                 lipgloss.Style
+}
+
+// newStyles builds the styles for a theme. The mono theme, or color: false
+// under output, uses no colors at all.
+func newStyles(cfg *config.Config) styles {
+	p, ok := palettes[cfg.TUI.Theme]
+	if !ok || !cfg.Output.Color {
+		plain := lipgloss.NewStyle()
+		return styles{
+			title:        plain.Bold(true).Reverse(true).Padding(0, 1),
+			item:         plain.Padding(0, 2),
+			selectedItem: plain.Bold(true).Padding(0, 2),
+			pagination:   plain,
+			help:         plain,
+			error:        plain.Bold(true),
+			status:       plain.Bold(true),
+			on:           plain,
+			off:          plain,
+			unavailable:  plain,
+			other:        plain,
+		}
+	}
+
+	color := func(c string) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+	}
+	return styles{
+		title: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color(p.titleFg)).
+			Background(lipgloss.Color(p.titleBg)).
+			Padding(0, 1),
+		item:         lipgloss.NewStyle().Padding(0, 2),
+		selectedItem: color(p.selected).Bold(true).Padding(0, 2),
+		pagination:   color(p.muted),
+		help:         color(p.muted),
+		error:        color(p.err).Bold(true),
+		status:       color(p.ok).Bold(true),
+		on:           color(p.ok),
+		off:          color(p.muted),
+		unavailable:  color(p.err),
+		other:        color(p.other),
+	}
+}
+
+var defaultKeys = map[string]string{
+	"up":      "up,k",
+	"down":    "down,j",
+	"toggle":  "enter,space",
+	"refresh": "r",
+	"quit":    "ctrl+c,q",
+}
+
+// newKeyMap maps key names to actions, applying the keybindings from the
+// config over the defaults. ctrl+c quits whatever the config says.
+func newKeyMap(cfg *config.Config) map[string]string {
+	keys := make(map[string]string)
+	for _, action := range config.TUIActions {
+		bindings, ok := cfg.TUI.Keybindings[action]
+		if !ok {
+			bindings = defaultKeys[action]
+		}
+		for _, key := range strings.Split(bindings, ",") {
+			key = strings.TrimSpace(key)
+			if key == "space" {
+				key = " "
+			}
+			if key != "" {
+				keys[key] = action
+			}
+		}
+	}
+	// ctrl+c always quits, so a custom quit binding can't lock the user in.
+	keys["ctrl+c"] = "quit"
+	return keys
+}
+
+// keyHelp describes the key bound to action for the help line.
+func keyHelp(keys map[string]string, action string) string {
+	var bound []string
+	for key, a := range keys {
+		if a == action {
+			if key == " " {
+				key = "space"
+			}
+			bound = append(bound, key)
+		}
+	}
+	if len(bound) == 0 {
+		return ""
+	}
+	// Named keys such as "enter" read better before single characters.
+	sort.Slice(bound, func(i, j int) bool {
+		if (len(bound[i]) > 1) != (len(bound[j]) > 1) {
+			return len(bound[i]) > 1
+		}
+		return bound[i] < bound[j]
+	})
+	return strings.Join(bound, "/")
+}
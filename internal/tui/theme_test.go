@@ -0,0 +1,24 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/quinncuatro/hass-cli/internal/config"
+)
+
+func TestNewKeyMapKeepsCtrlC(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TUI.Keybindings = map[string]string{"quit": "esc", "refresh": "ctrl+c,r"}
+
+	keys := newKeyMap(cfg)
+
+	if keys["esc"] != "quit" {
+		t.Errorf("expected esc to quit, got %q", keys["esc"])
+	}
+	if keys["ctrl+c"] != "quit" {
+		t.Errorf("expected ctrl+c to always quit, got %q", keys["ctrl+c"])
+	}
+	if _, ok := keys["q"]; ok {
+		t.Error("expected the default q binding to be replaced")
+	}
+}